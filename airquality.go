@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+)
+
+const (
+	airQualitySign = ""
+)
+
+// airQualityConfig selects where the air quality is fetched from, the
+// location is the one of the weather module
+type airQualityConfig struct {
+	// Provider is either open-meteo or waqi
+	Provider string `json:"provider"`
+	// Token for the World Air Quality Index API
+	Token   string   `json:"token,omitempty" secret:"true"`
+	Refresh duration `json:"refresh"`
+}
+
+// airQuality is the last reading, kept while the provider is unreachable
+var airQuality struct {
+	aqi, pm25 float64
+	at        time.Time
+	poll      poller
+}
+
+// updateAirQuality shows the US AQI and the PM2.5 concentration, colored
+// once the air becomes unhealthy for sensitive groups (AQI > 100) and for
+// everyone (AQI > 150)
+func updateAirQuality() string {
+	if airQuality.poll.due() {
+		airQuality.poll.refresh = cfg.AirQuality.Refresh.Duration
+		var aqi, pm25, err = fetchAirQuality()
+		airQuality.poll.done(err)
+		if err == nil {
+			airQuality.aqi, airQuality.pm25, airQuality.at = aqi, pm25, time.Now()
+		}
+	}
+	if airQuality.at.IsZero() {
+		return airQualitySign + " ERR"
+	}
+
+	var aqi = int(math.Round(airQuality.aqi))
+	var text = fmt.Sprintf("%s AQI %d PM2.5 %d", airQualitySign, aqi, int(math.Round(airQuality.pm25)))
+	switch {
+	case aqi > 150:
+		return colorUrgent + text + colorNormal
+	case aqi > 100:
+		return colorWarning + text + colorNormal
+	}
+	return text
+}
+
+// fetchAirQuality returns the US AQI and PM2.5 in µg/m³ at the weather
+// location
+func fetchAirQuality() (float64, float64, error) {
+	var lat, lon, err = weatherCoordinates()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch cfg.AirQuality.Provider {
+	case "open-meteo":
+		var query = url.Values{
+			"latitude":  {fmt.Sprint(lat)},
+			"longitude": {fmt.Sprint(lon)},
+			"current":   {"us_aqi,pm2_5"},
+		}
+		var data struct {
+			Current struct {
+				AQI  float64 `json:"us_aqi"`
+				PM25 float64 `json:"pm2_5"`
+			}
+		}
+		err = getJSON(weatherClient, "https://air-quality-api.open-meteo.com/v1/air-quality?"+query.Encode(), &data)
+		return data.Current.AQI, data.Current.PM25, err
+	case "waqi":
+		var data struct {
+			Status string
+			Data   struct {
+				AQI  float64
+				IAQI struct {
+					PM25 struct{ V float64 } `json:"pm25"`
+				}
+			}
+		}
+		err = getJSON(weatherClient, fmt.Sprintf("https://api.waqi.info/feed/geo:%f;%f/?token=%s",
+			lat, lon, url.QueryEscape(cfg.AirQuality.Token)), &data)
+		if err == nil && data.Status != "ok" {
+			err = fmt.Errorf("waqi: status %s", data.Status)
+		}
+		return data.Data.AQI, data.Data.IAQI.PM25.V, err
+	}
+	return 0, 0, fmt.Errorf("unknown air quality provider %q", cfg.AirQuality.Provider)
+}
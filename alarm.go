@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	alarmSign = ""
+)
+
+// alarmsConfig holds the alarms set in the config file
+type alarmsConfig struct {
+	// Notice is how long before an alarm it is shown in the bar
+	Notice duration `json:"notice"`
+	// Hook is run through sh when an alarm goes off with $ALARM_NAME set
+	Hook   string        `json:"hook,omitempty"`
+	Alarms []alarmConfig `json:"alarms,omitempty"`
+}
+
+// alarmConfig is a daily or weekly alarm
+type alarmConfig struct {
+	Name string `json:"name"`
+	// Time is the time of day as "15:04"
+	Time string `json:"time"`
+	// Days limits the alarm to some weekdays ("mon", "tue", …)
+	Days []string `json:"days,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// alarms holds the alarms set through the control socket, they are saved
+// in the alarms state file
+var alarms struct {
+	sync.Mutex
+	loaded    bool
+	added     []alarmConfig
+	lastCheck time.Time
+}
+
+// updateAlarms runs the hook of every alarm that went off since the last
+// update and shows the next alarm once it is closer than the notice period
+func updateAlarms() string {
+	alarms.Lock()
+	defer alarms.Unlock()
+	loadAlarms()
+
+	var now = time.Now()
+	var upcoming alarmConfig
+	var upcomingAt time.Time
+	for _, alarm := range allAlarms() {
+		if at, ok := alarm.next(alarms.lastCheck); ok && !at.After(now) {
+			runHook(cfg.Alarm.Hook, "ALARM_NAME="+alarm.Name)
+		}
+		if at, ok := alarm.next(now); ok && (upcomingAt.IsZero() || at.Before(upcomingAt)) {
+			upcoming, upcomingAt = alarm, at
+		}
+	}
+	alarms.lastCheck = now
+
+	if upcomingAt.IsZero() || upcomingAt.Sub(now) > cfg.Alarm.Notice.Duration {
+		return ""
+	}
+	return fmt.Sprintf("%s %s %s in %s", alarmSign, upcoming.Name, upcoming.Time,
+		clockDuration(upcomingAt.Sub(now)))
+}
+
+// allAlarms returns the configured alarms followed by the added ones
+func allAlarms() []alarmConfig {
+	var all = append([]alarmConfig{}, cfg.Alarm.Alarms...)
+	return append(all, alarms.added...)
+}
+
+// next returns when the alarm goes off after t
+func (a alarmConfig) next(t time.Time) (time.Time, bool) {
+	var clock, err = time.Parse("15:04", a.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for day := 0; day <= 7; day++ {
+		var at = time.Date(t.Year(), t.Month(), t.Day()+day, clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if at.After(t) && a.on(at.Weekday()) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// on reports whether the alarm is active on the given weekday
+func (a alarmConfig) on(day time.Weekday) bool {
+	if len(a.Days) == 0 {
+		return true
+	}
+	for _, name := range a.Days {
+		if d, ok := weekdays[strings.ToLower(name)]; ok && d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// alarmCommand adds an alarm (alarm add 07:30 [mon,tue,…] name), removes
+// one (alarm remove name) or lists them all (alarm list)
+func alarmCommand(args []string) (string, error) {
+	alarms.Lock()
+	defer refresh("alarm")
+	defer alarms.Unlock()
+	loadAlarms()
+
+	switch {
+	case len(args) >= 2 && args[0] == "add":
+		var alarm = alarmConfig{Time: args[1]}
+		if _, err := time.Parse("15:04", alarm.Time); err != nil {
+			return "", fmt.Errorf("invalid time %q, use HH:MM", alarm.Time)
+		}
+		args = args[2:]
+		if len(args) > 0 && isWeekdayList(args[0]) {
+			alarm.Days = strings.Split(args[0], ",")
+			args = args[1:]
+		}
+		alarm.Name = strings.Join(args, " ")
+		alarms.added = append(alarms.added, alarm)
+		return "", saveAlarms()
+	case len(args) >= 2 && args[0] == "remove":
+		var name = strings.Join(args[1:], " ")
+		var kept = alarms.added[:0]
+		for _, alarm := range alarms.added {
+			if alarm.Name != name {
+				kept = append(kept, alarm)
+			}
+		}
+		alarms.added = kept
+		return "", saveAlarms()
+	case len(args) == 1 && args[0] == "list":
+		var list []string
+		for _, alarm := range allAlarms() {
+			var days = "daily"
+			if len(alarm.Days) > 0 {
+				days = strings.Join(alarm.Days, ",")
+			}
+			list = append(list, fmt.Sprintf("%s %s %s", alarm.Time, days, alarm.Name))
+		}
+		return strings.Join(list, "; "), nil
+	}
+	return "", fmt.Errorf("usage: alarm add HH:MM [days] name|remove name|list")
+}
+
+// isWeekdayList reports whether s is a comma separated list of weekdays
+func isWeekdayList(s string) bool {
+	for _, day := range strings.Split(s, ",") {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// loadAlarms reads the alarms saved by saveAlarms on first use
+func loadAlarms() {
+	if alarms.loaded {
+		return
+	}
+	var data, err = ioutil.ReadFile(statePath("alarms.json"))
+	if err == nil {
+		json.Unmarshal(data, &alarms.added)
+	}
+	alarms.loaded = true
+	alarms.lastCheck = time.Now()
+}
+
+// saveAlarms keeps the alarms set through the control socket across restarts
+func saveAlarms() error {
+	var data, err = json.MarshalIndent(alarms.added, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(statePath("alarms.json")), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(statePath("alarms.json"), data, 0644)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	breakSign = ""
+)
+
+// breakConfig configures the break reminder
+type breakConfig struct {
+	// Every is the active time after which a break is due
+	Every duration `json:"every"`
+	// Length is how long the input has to be idle to count as a break
+	Length duration `json:"length"`
+	// Snooze postpones a due break by this long
+	Snooze duration `json:"snooze"`
+}
+
+// breaks tracks the time the user has been active since the last break
+var breaks struct {
+	sync.Mutex
+	active     time.Duration
+	lastUpdate time.Time
+}
+
+// updateBreak counts down the active time until the next break is due and
+// asks for a break once it is. Being idle for the break length resets it.
+func updateBreak() string {
+	var idle, err = idleTime()
+	if err != nil {
+		return breakSign + " ERR"
+	}
+
+	breaks.Lock()
+	defer breaks.Unlock()
+	var now = time.Now()
+	if idle >= cfg.Break.Length.Duration {
+		breaks.active = 0
+	} else if !breaks.lastUpdate.IsZero() {
+		breaks.active += now.Sub(breaks.lastUpdate)
+	}
+	breaks.lastUpdate = now
+
+	var left = cfg.Break.Every.Duration - breaks.active
+	if left <= 0 {
+		return colorWarning + breakSign + " take a break" + colorNormal
+	}
+	return fmt.Sprintf("%s %dm", breakSign, int(left.Minutes()))
+}
+
+// idleTime asks the X server how long there has been no input
+func idleTime() (time.Duration, error) {
+	var out, err = timedCommand("xprintidle").Output()
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	return time.Duration(ms) * time.Millisecond, err
+}
+
+// breakCommand snoozes a due break or resets the active time after a break
+// taken away from the keyboard's idle detection
+func breakCommand(args []string) (string, error) {
+	breaks.Lock()
+	defer refresh("break")
+	defer breaks.Unlock()
+	switch strings.Join(args, " ") {
+	case "snooze":
+		breaks.active = cfg.Break.Every.Duration - cfg.Break.Snooze.Duration
+	case "reset":
+		breaks.active = 0
+	default:
+		return "", fmt.Errorf("usage: break snooze|reset")
+	}
+	return "", nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// carouselConfig lists the modules that take turns in a single slot
+type carouselConfig struct {
+	Modules []string `json:"modules,omitempty"`
+	Rotate  duration `json:"rotate"`
+	// Width pads or cuts the texts to this many characters, so the rest of
+	// the bar doesn't move, 0 leaves them as they are
+	Width int `json:"width,omitempty"`
+}
+
+// the carousel reads the other modules, so it can't be part of the modules
+// literal
+func init() {
+	for _, m := range modules {
+		if m.name == "carousel" {
+			m.update = updateCarousel
+		}
+	}
+}
+
+// inCarousel reports whether the named module is shown by the carousel
+// instead of on its own
+func inCarousel(name string) bool {
+	for _, member := range cfg.Carousel.Modules {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCarousel shows the modules of the carousel one after another,
+// skipping those with nothing to show
+func updateCarousel() string {
+	statusMu.Lock()
+	var texts []string
+	for _, name := range cfg.Carousel.Modules {
+		for _, m := range modules {
+			if m.name == name && m.text != "" && !m.hidden {
+				texts = append(texts, m.text)
+			}
+		}
+	}
+	statusMu.Unlock()
+	if len(texts) == 0 {
+		return ""
+	}
+
+	var rotate = int64(cfg.Carousel.Rotate.Seconds())
+	if rotate < 1 {
+		rotate = 1
+	}
+	var text = texts[time.Now().Unix()/rotate%int64(len(texts))]
+	if width := cfg.Carousel.Width; width > 0 {
+		var runes = []rune(text)
+		if len(runes) > width {
+			text = string(runes[:width-1]) + "…"
+		} else {
+			text += strings.Repeat(" ", width-len(runes))
+		}
+	}
+	return text
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCarouselTurnsOnMembers(t *testing.T) {
+	var saved = cfg
+	var states = map[*module]module{}
+	for _, m := range modules {
+		states[m] = *m
+	}
+	defer func() {
+		cfg = saved
+		for m, state := range states {
+			m.off, m.text = state.off, state.text
+		}
+	}()
+
+	// weather is off by default and comes only through the carousel
+	if enabled("weather") {
+		t.Fatalf("weather is on by default")
+	}
+	cfg.Carousel = carouselConfig{Modules: []string{"weather"}}
+	enableModules()
+	if !enabled("weather") || enabled("ticker") {
+		t.Fatalf("enableModules() turned on weather %v, ticker %v", enabled("weather"), enabled("ticker"))
+	}
+
+	for _, m := range modules {
+		switch m.name {
+		case "weather":
+			m.text = "sunny 21°C"
+		case "clock":
+			m.text = "12:00"
+		}
+	}
+	if got := updateCarousel(); got != "sunny 21°C" {
+		t.Errorf("updateCarousel() = %q, want the weather", got)
+	}
+	if bar := render(); strings.Contains(bar, "sunny") || !strings.Contains(bar, "12:00") {
+		t.Errorf("render() = %q, want the clock without the weather", bar)
+	}
+}
+
+func TestEnable(t *testing.T) {
+	var saved = cfg
+	var off = map[*module]bool{}
+	for _, m := range modules {
+		off[m] = m.off
+	}
+	defer func() {
+		cfg = saved
+		for m := range off {
+			m.off = off[m]
+		}
+	}()
+
+	cfg.Enable = []string{"agenda"}
+	enableModules()
+	if !enabled("agenda") || enabled("weather") || !enabled("clock") {
+		t.Errorf("enable agenda: agenda %v, weather %v, clock %v",
+			enabled("agenda"), enabled("weather"), enabled("clock"))
+	}
+}
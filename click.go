@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// clickConfig enables clicking on modules with dwm's statuscmd patch
+type clickConfig struct {
+	// StatusCmd emits the delimiters the statuscmd patch expects in front of
+	// every module. As the patch takes the statuscolors escapes for
+	// delimiters as well, colors are left out then.
+	StatusCmd bool `json:"statuscmd,omitempty"`
+	// Commands are run when a module is clicked, the button is passed in
+	// $BUTTON, e.g. {"cpu": "st -e htop", "volume": "pavucontrol"}
+	Commands map[string]string `json:"commands,omitempty"`
+	// Actions are run for a single button of a module instead, like
+	// {"volume": {"3": ":volume mute", "4": ":volume up"}}. Buttons 4 and 5
+	// are scrolling up and down. Actions starting with a colon are control
+	// commands run by gods itself, see `gods ctl`.
+	Actions map[string]map[string]string `json:"actions,omitempty"`
+}
+
+// clickable reports whether clicking the named module does anything
+func clickable(name string) bool {
+	var _, command = cfg.Click.Commands[name]
+	var _, actions = cfg.Click.Actions[name]
+	return (command || actions) && enabled(name)
+}
+
+// click actions can run the other commands, so it can't be part of the
+// commands literal
+func init() {
+	commands["click"] = clickCommand
+}
+
+// clickNone is the delimiter in front of modules without click actions, it
+// is the highest one the statuscmd patch supports
+const clickNone = 31
+
+// clickID returns the number of the delimiter in front of the named module.
+// Modules with click actions are numbered from 1 in the order of the bar.
+func clickID(name string) int {
+	var id = 0
+	for _, m := range modules {
+		if clickable(m.name) {
+			id++
+			if m.name == name {
+				return id
+			}
+		}
+	}
+	return clickNone
+}
+
+// clickText prepares a module's text for the statuscmd patch
+func clickText(name, text string) string {
+	var id = clickID(name)
+	if id > clickNone {
+		id = clickNone
+	}
+	return string(rune(id)) + plainText(text)
+}
+
+// clickCommand runs the action of a clicked module, given by its
+// delimiter's number or its name. An action set for the button takes
+// precedence over the module's command.
+func clickCommand(args []string) (string, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return "", fmt.Errorf("usage: click <id>|<module> [button]")
+	}
+	var name = args[0]
+	if id, err := strconv.Atoi(args[0]); err == nil {
+		name = ""
+		for _, m := range modules {
+			if clickable(m.name) && clickID(m.name) == id {
+				name = m.name
+			}
+		}
+	}
+	var button = "1"
+	if len(args) == 2 {
+		button = args[1]
+	}
+
+	var action, ok = cfg.Click.Actions[name][button]
+	if !ok {
+		action, ok = cfg.Click.Commands[name]
+	}
+	if !ok {
+		return "", nil // e.g. scrolling over a module that is only clicked
+	}
+	if strings.HasPrefix(action, ":") {
+		var args = strings.Fields(action[1:])
+		if len(args) == 0 || args[0] == "click" {
+			return "", fmt.Errorf("bad click action %q", action)
+		} else if command, ok := commands[args[0]]; ok {
+			return command(args[1:])
+		}
+		return "", fmt.Errorf("unknown command %q", args[0])
+	}
+	runHook(action, "BUTTON="+button, "GODS_MODULE="+name)
+	return "", nil
+}
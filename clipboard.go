@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	clipboardSign = ""
+)
+
+// clipboardConfig selects what the clipboard preview shows
+type clipboardConfig struct {
+	// Selection is "clipboard" or "primary"
+	Selection string `json:"selection"`
+	// Length is the number of characters shown
+	Length int `json:"length"`
+}
+
+var clipboard struct {
+	sync.Mutex
+	hidden bool
+}
+
+// updateClipboard shows the start of the current selection. It is hidden
+// when toggled off and while the selection comes from a password manager.
+func updateClipboard() string {
+	clipboard.Lock()
+	var hidden = clipboard.hidden
+	clipboard.Unlock()
+	if hidden {
+		return ""
+	}
+
+	// KeePassXC, KWallet and others mark passwords with this target
+	var targets, _ = timedCommand("xclip", "-o", "-selection", cfg.Clipboard.Selection, "-t", "TARGETS").Output()
+	if strings.Contains(string(targets), "x-kde-passwordManagerHint") {
+		return clipboardSign + " ***"
+	}
+	var out, err = timedCommand("xclip", "-o", "-selection", cfg.Clipboard.Selection).Output()
+	if err != nil {
+		return "" // the selection is empty
+	}
+
+	// collapse whitespace and drop control characters, including the
+	// statuscolors escapes
+	var preview = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, strings.Join(strings.Fields(string(out)), " "))
+	if preview == "" {
+		return ""
+	}
+	if runes := []rune(preview); len(runes) > cfg.Clipboard.Length {
+		preview = string(runes[:cfg.Clipboard.Length]) + "…"
+	}
+	return clipboardSign + " " + preview
+}
+
+// clipboardCommand hides the preview, e.g. while sharing the screen
+func clipboardCommand(args []string) (string, error) {
+	clipboard.Lock()
+	defer refresh("clipboard")
+	defer clipboard.Unlock()
+	switch strings.Join(args, " ") {
+	case "toggle":
+		clipboard.hidden = !clipboard.hidden
+	case "hide":
+		clipboard.hidden = true
+	case "show":
+		clipboard.hidden = false
+	default:
+		return "", fmt.Errorf("usage: clipboard toggle|hide|show")
+	}
+	return "", nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockConfig describes one clock field
+type clockConfig struct {
+	// Format is a Go reference time layout, e.g. "Mon Jan 02 15:04"
+	Format string `json:"format"`
+	// Locale selects the language of month and day names, e.g. "de_DE"
+	Locale string `json:"locale,omitempty"`
+	// Timezone is an IANA zone like "America/New_York", default is local time
+	Timezone string `json:"timezone,omitempty"`
+	// Label is put in front of the time, e.g. "NYC"
+	Label string `json:"label,omitempty"`
+}
+
+// zones caches the loaded clock time zones
+var zones = map[string]*time.Location{}
+
+// localeNames holds the translations of the month and day names
+type localeNames struct {
+	months, shortMonths, days, shortDays []string
+}
+
+// locales maps a language code to its month and day names, English is used
+// for all others
+var locales = map[string]localeNames{
+	"de": {
+		months:      []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: []string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		days:        []string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   []string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"fr": {
+		months:      []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: []string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+		days:        []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   []string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+	},
+	"es": {
+		months:      []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		days:        []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   []string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"it": {
+		months:      []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        []string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   []string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: []string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        []string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   []string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: []string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        []string{"domingo", "segunda", "terça", "quarta", "quinta", "sexta", "sábado"},
+		shortDays:   []string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+	"sv": {
+		months:      []string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		shortMonths: []string{"jan", "feb", "mar", "apr", "maj", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        []string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		shortDays:   []string{"sön", "mån", "tis", "ons", "tor", "fre", "lör"},
+	},
+}
+
+// layoutTokens are the layout elements formatClock handles on its own,
+// longest first so "January" is not mistaken for "Jan". Besides the names
+// {week} prints the ISO week number and {yday} the day of the year.
+var layoutTokens = []string{"January", "Monday", "Jan", "Mon", "{week}", "{yday}"}
+
+// updateClocks formats the current time for every configured clock. It makes
+// room for the next departure if the transit module rotates with the clock.
+func updateClocks() string {
+	var now = time.Now()
+	if transitTurn(now) {
+		return ""
+	}
+	var clocks []string
+	for _, clock := range cfg.Clocks {
+		var text = ""
+		if zone, err := loadZone(clock.Timezone); err != nil {
+			text = "ERR"
+		} else {
+			text = formatClock(now.In(zone), clock)
+		}
+		if clock.Label != "" {
+			text = clock.Label + " " + text
+		}
+		clocks = append(clocks, text)
+	}
+	return strings.Join(clocks, fieldSeparator)
+}
+
+// loadZone looks up the named time zone, the empty name is local time
+func loadZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	} else if zone, ok := zones[name]; ok {
+		return zone, nil
+	}
+	var zone, err = time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	zones[name] = zone
+	return zone, nil
+}
+
+// formatClock formats t like time.Format, but with the month and day names of
+// the clock's locale and the additional {week} and {yday} tokens
+func formatClock(t time.Time, clock clockConfig) string {
+	var lang = strings.ToLower(clock.Locale)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	var names, localized = locales[lang]
+
+	var out = ""
+	for layout := clock.Format; layout != ""; {
+		var pos, token = -1, ""
+		for _, tok := range layoutTokens {
+			if i := strings.Index(layout, tok); i >= 0 && (pos < 0 || i < pos) {
+				pos, token = i, tok
+			}
+		}
+		if pos < 0 {
+			out += t.Format(layout)
+			break
+		}
+
+		out += t.Format(layout[:pos])
+		switch {
+		case token == "{week}":
+			var _, week = t.ISOWeek()
+			out += fmt.Sprintf("%02d", week)
+		case token == "{yday}":
+			out += fmt.Sprintf("%03d", t.YearDay())
+		case !localized:
+			out += t.Format(token)
+		case token == "January":
+			out += names.months[t.Month()-1]
+		case token == "Jan":
+			out += names.shortMonths[t.Month()-1]
+		case token == "Monday":
+			out += names.days[t.Weekday()]
+		case token == "Mon":
+			out += names.shortDays[t.Weekday()]
+		}
+		layout = layout[pos+len(token):]
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The modules showing the CPU, memory, battery and network usage and the CPU
+// temperature get their numbers from the functions below, which are
+// implemented once per operating system in collect_<os>.go:
+//
+//	cpuUsage() (float64, error)                       percentage of all cores in use
+//	memoryUsage() (used, total float64, err error)    in KiB
+//	readBattery() (battery, error)
+//	netCounters() (map[string]netCounter, error)      by interface
+//	cpuTemperature() (float64, error)                 in °C
+
+// battery is the charge of all batteries together. now and full may be in any
+// unit as long as it is the same for both, e.g. µWh or percent.
+type battery struct {
+	now, full float64
+	plugged   bool
+}
+
+// netCounter holds the bytes an interface received and sent so far
+type netCounter struct {
+	rx, tx uint64
+}
+
+// collector provides the numbers of the modules above, the volume is always
+// read through cfg.Volume.Backends
+type collector interface {
+	cpuUsage() (float64, error)
+	memoryUsage() (used, total float64, err error)
+	readBattery() (battery, error)
+	netCounters() (map[string]netCounter, error)
+	cpuTemperature() (float64, error)
+}
+
+// collectors can be chosen by cfg.Collector. Building with -tags gopsutil
+// adds "gopsutil" and makes it the default.
+var collectors = map[string]collector{
+	"native": native{},
+}
+
+// sys is the collector in use
+var sys collector = native{}
+
+// native uses the parsers in collect_<os>.go
+type native struct{}
+
+func (native) cpuUsage() (float64, error)                    { return cpuUsage() }
+func (native) memoryUsage() (used, total float64, err error) { return memoryUsage() }
+func (native) readBattery() (battery, error)                 { return readBattery() }
+func (native) netCounters() (map[string]netCounter, error)   { return netCounters() }
+func (native) cpuTemperature() (float64, error)              { return cpuTemperature() }
+
+// errNoBattery is returned by readBattery on desktops
+var errNoBattery = errors.New("no battery found")
+
+// errUnsupported is returned by the collectors without an implementation for
+// the operating system, the modules show ERR then
+var errUnsupported = errors.New("not supported on this system")
+
+// procPath and sysPath locate a file like "/proc/loadavg" in procfs or sysfs.
+// Like gopsutil they honor $HOST_PROC and $HOST_SYS, e.g. for reading the
+// host's /proc bind-mounted into a container or a recorded copy of it.
+func procPath(path string) string {
+	return hostPath("HOST_PROC", "/proc", path)
+}
+
+func sysPath(path string) string {
+	return hostPath("HOST_SYS", "/sys", path)
+}
+
+// hostPath moves the path from below mount to below the root in env
+func hostPath(env, mount, path string) string {
+	var root = os.Getenv(env)
+	if root == "" {
+		return path
+	}
+	return filepath.Join(root, strings.TrimPrefix(path, mount))
+}
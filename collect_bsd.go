@@ -0,0 +1,56 @@
+//go:build freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// cpuTimes holds kern.cp_time from the previous call of cpTimeUsage
+var cpuTimes struct {
+	sync.Mutex
+	last []int64
+}
+
+// cpTimeUsage returns the share of the time not spent idle since the last
+// call, from the ticks kern.cp_time counts per state in integers of width
+// bytes
+func cpTimeUsage(states, idle, width int) (float64, error) {
+	var raw, err = syscall.Sysctl("kern.cp_time")
+	if err != nil {
+		return 0, err
+	}
+	// Sysctl drops a trailing zero byte
+	var buf = make([]byte, states*width)
+	if len(raw) > len(buf) {
+		return 0, fmt.Errorf("kern.cp_time: unexpected size %d", len(raw))
+	}
+	copy(buf, raw)
+	var times = make([]int64, states)
+	for i := range times {
+		if width == 8 {
+			times[i] = *(*int64)(unsafe.Pointer(&buf[i*8]))
+		} else {
+			times[i] = int64(*(*int32)(unsafe.Pointer(&buf[i*4])))
+		}
+	}
+
+	cpuTimes.Lock()
+	defer cpuTimes.Unlock()
+	var last = cpuTimes.last
+	cpuTimes.last = times
+	if last == nil {
+		return 0, nil
+	}
+	var total int64
+	for i := range times {
+		total += times[i] - last[i]
+	}
+	if total <= 0 {
+		return 0, nil
+	}
+	return float64(total-(times[idle]-last[idle])) * 100 / float64(total), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sysctlInt reads an integer of 4 or 8 bytes. Sysctl drops a trailing zero
+// byte, so e.g. 3 bytes are left of a small 4 byte integer.
+func sysctlInt(name string) (float64, error) {
+	var raw, err = syscall.Sysctl(name)
+	if err != nil {
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[:], raw)
+	switch {
+	case len(raw) <= 4:
+		return float64(*(*uint32)(unsafe.Pointer(&buf[0]))), nil
+	case len(raw) <= 8:
+		return float64(*(*uint64)(unsafe.Pointer(&buf[0]))), nil
+	}
+	return 0, fmt.Errorf("%s: unexpected size %d", name, len(raw))
+}
+
+// cpuUsage reads the last minute sysload from struct loadavg and scales it to
+// the core count
+func cpuUsage() (float64, error) {
+	var raw, err = syscall.Sysctl("vm.loadavg")
+	if err != nil {
+		return 0, err
+	}
+	// uint32_t ldavg[3], followed by long fscale
+	var buf [24]byte
+	if len(raw) < 16 {
+		return 0, fmt.Errorf("vm.loadavg: unexpected size %d", len(raw))
+	}
+	copy(buf[:], raw)
+	var load = *(*uint32)(unsafe.Pointer(&buf[0]))
+	var fscale = *(*int64)(unsafe.Pointer(&buf[16]))
+	if fscale == 0 {
+		return 0, fmt.Errorf("vm.loadavg: no fscale")
+	}
+	return float64(load) / float64(fscale) * 100 / float64(cores), nil
+}
+
+// memoryUsage counts the pages neither free nor backed by files as used,
+// which leaves out the file cache like on Linux
+func memoryUsage() (used, total float64, err error) {
+	var values = map[string]float64{}
+	for _, name := range []string{"hw.memsize", "hw.pagesize", "vm.page_free_count",
+		"vm.page_speculative_count", "vm.page_pageable_external_count"} {
+		if values[name], err = sysctlInt(name); err != nil {
+			return 0, 0, err
+		}
+	}
+	total = values["hw.memsize"] / 1024
+	used = total - (values["vm.page_free_count"]+values["vm.page_speculative_count"]+
+		values["vm.page_pageable_external_count"])*values["hw.pagesize"]/1024
+	return used, total, nil
+}
+
+// pmsetRx matches the charge of a battery in the output of pmset
+var pmsetRx = regexp.MustCompile(`\t(\d+)%;`)
+
+// readBattery asks pmset(1) for the charge of the batteries in percent, the
+// standard library can't talk to IOKit
+func readBattery() (battery, error) {
+	var batt battery
+	var out, err = timedCommand("pmset", "-g", "batt").Output()
+	if err != nil {
+		return batt, err
+	}
+	batt.plugged = strings.Contains(string(out), "'AC Power'")
+	for _, match := range pmsetRx.FindAllStringSubmatch(string(out), -1) {
+		var percent, _ = strconv.Atoi(match[1])
+		batt.now += float64(percent)
+		batt.full += 100
+	}
+	if batt.full == 0 {
+		return batt, errNoBattery
+	}
+	return batt, nil
+}
+
+func cpuTemperature() (float64, error) {
+	return 0, errUnsupported
+}
+
+// the volume is read and changed through AppleScript on macOS
+func init() {
+	volumeBackends["osascript"] = volumeBackend{osascriptVolume, osascriptCommand}
+	cfg.Volume.Backends = []string{"osascript"}
+}
+
+// osascriptVolume reads the output volume through AppleScript
+func osascriptVolume() (percent int, muted bool, err error) {
+	out, err := timedCommand("osascript", "-e", "get volume settings").Output()
+	if err != nil {
+		return 0, false, err
+	}
+	// output volume:50, input volume:75, alert volume:100, output muted:false
+	for _, setting := range strings.Split(strings.TrimSpace(string(out)), ", ") {
+		var kv = strings.SplitN(setting, ":", 2)
+		switch {
+		case len(kv) != 2:
+		case kv[0] == "output volume":
+			percent, err = strconv.Atoi(kv[1])
+		case kv[0] == "output muted":
+			muted = kv[1] == "true"
+		}
+	}
+	return percent, muted, err
+}
+
+// osascriptCommand changes the output volume through AppleScript
+func osascriptCommand(action string) error {
+	var script = map[string]string{
+		"mute": "set volume output muted not (output muted of (get volume settings))",
+		"up":   fmt.Sprintf("set volume output volume (output volume of (get volume settings) + %d)", volumeStep),
+		"down": fmt.Sprintf("set volume output volume (output volume of (get volume settings) - %d)", volumeStep),
+	}[action]
+	return timedCommand("osascript", "-e", script).Run()
+}
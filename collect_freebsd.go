@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strconv"
+	"syscall"
+)
+
+// cpuUsage returns the share of the time not spent idle since the last call,
+// kern.cp_time counts user, nice, sys, intr and idle ticks
+func cpuUsage() (float64, error) {
+	return cpTimeUsage(5, 4, strconv.IntSize/8) // C longs
+}
+
+// memoryUsage counts the pages neither free nor inactive as used
+func memoryUsage() (used, total float64, err error) {
+	var pages = map[string]uint32{}
+	for _, name := range []string{"hw.pagesize", "vm.stats.vm.v_page_count",
+		"vm.stats.vm.v_free_count", "vm.stats.vm.v_inactive_count"} {
+		if pages[name], err = syscall.SysctlUint32(name); err != nil {
+			return 0, 0, err
+		}
+	}
+	// removed in FreeBSD 12
+	var cache, _ = syscall.SysctlUint32("vm.stats.vm.v_cache_count")
+
+	var kib = float64(pages["hw.pagesize"]) / 1024
+	total = float64(pages["vm.stats.vm.v_page_count"]) * kib
+	used = total - float64(pages["vm.stats.vm.v_free_count"]+
+		pages["vm.stats.vm.v_inactive_count"]+cache)*kib
+	return used, total, nil
+}
+
+// readBattery reads the combined charge of all batteries from acpi(4), in
+// percent
+func readBattery() (battery, error) {
+	var batt = battery{full: 100}
+	// missing without acpi_battery(4), -1 if there is no battery
+	var life, err = syscall.SysctlUint32("hw.acpi.battery.life")
+	if err != nil || life > 100 {
+		return batt, errNoBattery
+	}
+	acline, err := syscall.SysctlUint32("hw.acpi.acline")
+	if err != nil {
+		return batt, err
+	}
+	batt.plugged = acline == 1
+	batt.now = float64(life)
+	return batt, nil
+}
+
+func cpuTemperature() (float64, error) {
+	return 0, errUnsupported
+}
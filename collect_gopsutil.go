@@ -0,0 +1,82 @@
+//go:build gopsutil
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// gopsutil reads the numbers through github.com/shirou/gopsutil, which
+// supports more systems than the native parsers
+type gopsutil struct{}
+
+func init() {
+	collectors["gopsutil"] = gopsutil{}
+	cfg.Collector = "gopsutil"
+}
+
+// cpuUsage returns the share of the time not spent idle since the last call
+func (gopsutil) cpuUsage() (float64, error) {
+	var percent, err = cpu.Percent(0, false)
+	if err != nil {
+		return 0, err
+	} else if len(percent) == 0 {
+		return 0, fmt.Errorf("no cpu times")
+	}
+	return percent[0], nil
+}
+
+// readBattery is native, gopsutil doesn't read batteries
+func (gopsutil) readBattery() (battery, error) {
+	return readBattery()
+}
+
+func (gopsutil) memoryUsage() (used, total float64, err error) {
+	stat, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, 0, err
+	}
+	return float64(stat.Used) / 1024, float64(stat.Total) / 1024, nil
+}
+
+// netCounters reads the interfaces in netDevs on Linux like the native
+// parser, and all but loopback elsewhere
+func (gopsutil) netCounters() (map[string]netCounter, error) {
+	stats, err := net.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+	var counters = map[string]netCounter{}
+	for _, stat := range stats {
+		var _, listed = netDevs[stat.Name+":"]
+		if listed || runtime.GOOS != "linux" && !strings.HasPrefix(stat.Name, "lo") {
+			counters[stat.Name] = netCounter{stat.BytesRecv, stat.BytesSent}
+		}
+	}
+	return counters, nil
+}
+
+// cpuTemperature returns the first sensor that looks like it belongs to the
+// CPU package
+func (gopsutil) cpuTemperature() (float64, error) {
+	var temps, err = host.SensorsTemperatures()
+	if err != nil && len(temps) == 0 {
+		return 0, err
+	}
+	for _, temp := range temps {
+		var key = strings.ToLower(temp.SensorKey)
+		for _, name := range []string{"package", "k10temp", "tctl", "cpu"} {
+			if strings.Contains(key, name) {
+				return temp.Temperature, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no cpu temperature sensor found")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cpuUsage reads the last minute sysload and scales it to the core count
+func cpuUsage() (float64, error) {
+	var load float64
+	var loadavg, err = ioutil.ReadFile(procPath("/proc/loadavg"))
+	if err != nil {
+		return 0, err
+	}
+	if _, err = fmt.Sscanf(string(loadavg), "%f", &load); err != nil {
+		return 0, err
+	}
+	return load * 100 / float64(cores), nil
+}
+
+// memoryUsage reads the memory used by applications, which excludes buffers
+// and the page cache
+func memoryUsage() (used, total float64, err error) {
+	file, err := os.Open(procPath("/proc/meminfo"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	// done must equal the flag combination (0001 | 0010 | 0100 | 1000) = 15
+	var done = 0
+	for info := bufio.NewScanner(file); done != 15 && info.Scan(); {
+		var prop, val = "", 0.0
+		if _, err = fmt.Sscanf(info.Text(), "%s %f", &prop, &val); err != nil {
+			return 0, 0, err
+		}
+		switch prop {
+		case "MemTotal:":
+			total = val
+			used += val
+			done |= 1
+		case "MemFree:":
+			used -= val
+			done |= 2
+		case "Buffers:":
+			used -= val
+			done |= 4
+		case "Cached:":
+			used -= val
+			done |= 8
+		}
+	}
+	return used, total, nil
+}
+
+// readBattery sums up the energy of all system batteries in sysfs. The power
+// supplies are told apart by their type rather than their names, which vary
+// from AC over AC0 and ADP1 to ACAD. Without any adapter, being plugged in is
+// inferred from the batteries not discharging.
+func readBattery() (battery, error) {
+	var powerSupply = sysPath("/sys/class/power_supply") + "/"
+	var batt battery
+	supplies, err := ioutil.ReadDir(powerSupply)
+	if err != nil {
+		return batt, err
+	}
+
+	readstr := func(name, field string) string {
+		var content, _ = ioutil.ReadFile(powerSupply + name + "/" + field)
+		return strings.TrimSpace(string(content))
+	}
+	readint := func(name, field string) (float64, bool) {
+		var n, err = strconv.Atoi(readstr(name, field))
+		return float64(n), err == nil
+	}
+	// readval reads energy in µWh. Batteries reporting their charge in µAh
+	// are converted by their voltage in µV, so batteries of both kinds add up.
+	readval := func(name, field string) float64 {
+		if energy, ok := readint(name, "energy_"+field); ok {
+			return energy
+		}
+		var charge, _ = readint(name, "charge_"+field)
+		if voltage, ok := readint(name, "voltage_now"); ok && voltage > 0 {
+			return charge * voltage / 1e6
+		}
+		return charge
+	}
+
+	var adapters, batteries, discharging = 0, 0, false
+	for _, supply := range supplies {
+		name := supply.Name()
+		switch readstr(name, "type") {
+		case "Mains", "USB":
+			adapters++
+			batt.plugged = batt.plugged || readstr(name, "online") == "1"
+		case "Battery":
+			// the batteries of mice and the like have the scope Device
+			if readstr(name, "scope") == "Device" {
+				continue
+			}
+			batteries++
+			batt.full += readval(name, "full")
+			batt.now += readval(name, "now")
+			discharging = discharging || readstr(name, "status") == "Discharging"
+		}
+	}
+	if adapters == 0 {
+		batt.plugged = !discharging
+	}
+	if batteries == 0 {
+		return batt, errNoBattery
+	} else if batt.full == 0 { // Battery found but no readable full file.
+		return batt, fmt.Errorf("no readable battery found")
+	}
+	return batt, nil
+}
+
+// netCounters reads the traffic of the interfaces in netDevs. Names and
+// numbers may run together in /proc/net/dev, like "eth0:12345".
+func netCounters() (map[string]netCounter, error) {
+	content, err := ioutil.ReadFile(procPath("/proc/net/dev"))
+	if err != nil {
+		return nil, err
+	}
+
+	var counters = map[string]netCounter{}
+	for _, line := range strings.Split(string(content), "\n") {
+		var colon = strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+		var dev = strings.TrimSpace(line[:colon])
+		var fields = strings.Fields(line[colon+1:])
+		if _, ok := netDevs[dev+":"]; !ok || len(fields) < 9 {
+			continue
+		}
+		var counter netCounter
+		if counter.rx, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+			return nil, err
+		}
+		if counter.tx, err = strconv.ParseUint(fields[8], 10, 64); err != nil {
+			return nil, err
+		}
+		counters[dev] = counter
+	}
+	return counters, nil
+}
+
+// cpuTempSources are tried in this order for the CPU temperature
+var cpuTempSources = []string{"hwmon", "thermal"}
+
+// cpuTempFallback remembers the first of cpuTempSources that works
+var cpuTempFallback fallback
+
+func init() {
+	fallbacks["cputemp"] = &cpuTempFallback
+}
+
+// cpuTemperature reads the CPU package temperature from the first source
+// that works: the hwmon of the CPU's driver, or else a thermal zone
+func cpuTemperature() (temp float64, err error) {
+	err = cpuTempFallback.try(cpuTempSources, func(source string) error {
+		var millis int
+		var err error
+		if source == "hwmon" {
+			millis, err = hwmonCPUTemp()
+		} else {
+			millis, err = thermalCPUTemp()
+		}
+		temp = float64(millis) / 1000
+		return err
+	})
+	return temp, err
+}
+
+// hwmonCPUTemp reads the first input of the CPU's hwmon, which is the package
+// for coretemp and Tctl for k10temp
+func hwmonCPUTemp() (int, error) {
+	var names, _ = filepath.Glob(sysPath("/sys/class/hwmon/hwmon*/name"))
+	for _, name := range names {
+		var driver, _ = ioutil.ReadFile(name)
+		switch strings.TrimSpace(string(driver)) {
+		case "coretemp", "k10temp", "zenpower", "cpu_thermal":
+			return readProcInt(filepath.Join(filepath.Dir(name), "temp1_input"))
+		}
+	}
+	return 0, fmt.Errorf("no hwmon of a cpu found")
+}
+
+// thermalCPUTemp reads the thermal zone of the CPU package, or the second one
+// which is the package on most laptops
+func thermalCPUTemp() (int, error) {
+	var types, _ = filepath.Glob(sysPath("/sys/class/thermal/thermal_zone*/type"))
+	for _, zone := range types {
+		var kind, _ = ioutil.ReadFile(zone)
+		switch strings.TrimSpace(string(kind)) {
+		case "x86_pkg_temp", "cpu-thermal", "cpu_thermal":
+			return readProcInt(filepath.Join(filepath.Dir(zone), "temp"))
+		}
+	}
+	return readProcInt(sysPath("/sys/class/thermal/thermal_zone1/temp"))
+}
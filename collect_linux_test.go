@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// the fixture trees below testdata are recorded /proc and /sys excerpts
+func useFixture(t *testing.T, machine string) {
+	t.Setenv("HOST_PROC", "testdata/"+machine+"/proc")
+	t.Setenv("HOST_SYS", "testdata/"+machine+"/sys")
+}
+
+func TestCPUUsage(t *testing.T) {
+	useFixture(t, "laptop")
+	defer func(n int) { cores = n }(cores)
+	cores = 4
+
+	var usage, err = cpuUsage()
+	if err != nil || usage != 37.5 {
+		t.Errorf("cpuUsage() = %v, %v, want 37.5", usage, err)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	useFixture(t, "laptop")
+	var used, total, err = memoryUsage()
+	if err != nil || used != 8000000 || total != 16000000 {
+		t.Errorf("memoryUsage() = %v, %v, %v, want 8000000, 16000000", used, total, err)
+	}
+}
+
+func TestReadBattery(t *testing.T) {
+	var tests = []struct {
+		machine string
+		want    battery
+		err     error
+	}{
+		// BAT1 reports its charge, 4Ah at 12V are 48Wh. The mouse's battery
+		// is left out.
+		{"laptop", battery{now: 54000000, full: 98000000}, nil},
+		{"desktop", battery{plugged: true}, errNoBattery},
+		// no adapter, plugged in since the battery isn't discharging
+		{"tablet", battery{now: 3000000, full: 4000000, plugged: true}, nil},
+	}
+	for _, test := range tests {
+		useFixture(t, test.machine)
+		var got, err = readBattery()
+		if got != test.want || err != test.err {
+			t.Errorf("%s: readBattery() = %+v, %v, want %+v, %v", test.machine, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestNetCounters(t *testing.T) {
+	var tests = []struct {
+		machine string
+		want    map[string]netCounter
+	}{
+		// wlan0 runs into its counter, lo and docker0 aren't in netDevs
+		{"laptop", map[string]netCounter{"wlan0": {rx: 123456789, tx: 23456789}}},
+		{"desktop", map[string]netCounter{
+			"eth0": {rx: 18446744073709551000, tx: 9000000000},
+			"eth1": {rx: 2000, tx: 1000},
+		}},
+	}
+	for _, test := range tests {
+		useFixture(t, test.machine)
+		var got, err = netCounters()
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: netCounters() = %v, %v, want %v", test.machine, got, err, test.want)
+		}
+	}
+	useFixture(t, "tablet")
+	if _, err := netCounters(); err == nil {
+		t.Errorf("tablet: netCounters() without /proc/net/dev succeeded")
+	}
+}
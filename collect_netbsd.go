@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// cpuUsage returns the share of the time not spent idle since the last call,
+// kern.cp_time counts user, nice, sys, intr and idle ticks as uint64
+func cpuUsage() (float64, error) {
+	return cpTimeUsage(5, 4, 8)
+}
+
+// memoryUsage counts the pages neither free nor inactive as used, taken from
+// struct uvmexp_sysctl whose fields are all int64
+func memoryUsage() (used, total float64, err error) {
+	raw, err := syscall.Sysctl("vm.uvmexp2")
+	if err != nil {
+		return 0, 0, err
+	}
+	const pagesize, npages, free, inactive = 0, 3, 4, 6
+	var buf = make([]byte, 8*(inactive+1))
+	if len(raw) < len(buf) {
+		return 0, 0, fmt.Errorf("vm.uvmexp2: unexpected size %d", len(raw))
+	}
+	copy(buf, raw)
+	var field = func(i int) float64 {
+		return float64(*(*int64)(unsafe.Pointer(&buf[i*8])))
+	}
+	var kib = field(pagesize) / 1024
+	total = field(npages) * kib
+	used = total - (field(free)+field(inactive))*kib
+	return used, total, nil
+}
+
+// readBattery sums up the charge of the acpibat sensors of envsys(4)
+func readBattery() (battery, error) {
+	var batt battery
+	var sensors, err = envstat()
+	if err != nil {
+		return batt, err
+	}
+	for device, values := range sensors {
+		switch {
+		case strings.HasPrefix(device, "acpiacad"):
+			batt.plugged = batt.plugged || values["connected"] == "TRUE"
+		case strings.HasPrefix(device, "acpibat") && values["present"] == "TRUE":
+			var full, _ = strconv.ParseFloat(values["last full cap"], 64)
+			var now, _ = strconv.ParseFloat(values["charge"], 64)
+			batt.full += full
+			batt.now += now
+		}
+	}
+	if batt.full == 0 {
+		return batt, errNoBattery
+	}
+	return batt, nil
+}
+
+// cpuTemperature reads the first CPU's sensor of coretemp(4) or amdtemp(4)
+func cpuTemperature() (float64, error) {
+	var sensors, err = envstat()
+	if err != nil {
+		return 0, err
+	}
+	for _, device := range []string{"coretemp0", "amdtemp0"} {
+		for name, value := range sensors[device] {
+			if strings.HasSuffix(name, "temperature") {
+				return strconv.ParseFloat(value, 64)
+			}
+		}
+	}
+	return 0, fmt.Errorf("no cpu temperature sensor found")
+}
+
+// envstat reads the current values of all envsys(4) sensors by device, e.g.
+// "acpibat0" -> "charge" -> "30.010"
+func envstat() (map[string]map[string]string, error) {
+	var out, err = timedCommand("envstat").Output()
+	if err != nil {
+		return nil, err
+	}
+	var sensors = map[string]map[string]string{}
+	var device map[string]string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			device = map[string]string{}
+			sensors[line[1:len(line)-1]] = device
+			continue
+		}
+		var colon = strings.Index(line, ":")
+		if device == nil || colon < 0 {
+			continue
+		}
+		if value := strings.Fields(line[colon+1:]); len(value) > 0 {
+			device[line[:colon]] = value[0]
+		}
+	}
+	return sensors, nil
+}
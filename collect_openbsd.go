@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cpuUsage returns the share of the time not spent idle since the last call,
+// kern.cp_time counts user, nice, sys, spin, intr and idle ticks
+func cpuUsage() (float64, error) {
+	return cpTimeUsage(6, 5, strconv.IntSize/8) // C longs
+}
+
+// memoryUsage counts the pages neither free nor inactive as used. The
+// standard library can't read vm.uvmexp, so they are taken from vmstat -s.
+func memoryUsage() (used, total float64, err error) {
+	out, err := timedCommand("vmstat", "-s").Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	var stats = map[string]float64{}
+	for _, line := range strings.Split(string(out), "\n") {
+		var fields = strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) == 2 {
+			stats[fields[1]], _ = strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	var kib = stats["bytes per page"] / 1024
+	total = stats["pages managed"] * kib
+	if total == 0 {
+		return 0, 0, fmt.Errorf("vmstat: no page counts")
+	}
+	used = total - (stats["pages free"]+stats["pages inactive"])*kib
+	return used, total, nil
+}
+
+// readBattery asks apm(8), which gets the APM_IOC_GETPOWER ioctl of apm(4)
+// done without raw system calls, for the combined charge in percent
+func readBattery() (battery, error) {
+	var batt = battery{full: 100}
+	var ac, err = apm("-a")
+	if err != nil {
+		return batt, err
+	}
+	life, err := apm("-l")
+	if err != nil {
+		return batt, err
+	}
+	if life < 0 || life > 100 { // 255 without a battery
+		return batt, errNoBattery
+	}
+	batt.plugged = ac == 1
+	batt.now = float64(life)
+	return batt, nil
+}
+
+// apm prints a single number for each of its flags
+func apm(flag string) (int, error) {
+	var out, err = timedCommand("apm", flag).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+func cpuTemperature() (float64, error) {
+	return 0, errUnsupported
+}
+
+// sndio is OpenBSD's sound system
+func init() {
+	cfg.Volume.Backends = []string{"sndio"}
+}
@@ -0,0 +1,23 @@
+//go:build !darwin && !linux && !freebsd && !netbsd && !openbsd
+
+package main
+
+func cpuUsage() (float64, error) {
+	return 0, errUnsupported
+}
+
+func memoryUsage() (used, total float64, err error) {
+	return 0, 0, errUnsupported
+}
+
+func readBattery() (battery, error) {
+	return battery{}, errUnsupported
+}
+
+func netCounters() (map[string]netCounter, error) {
+	return nil, errUnsupported
+}
+
+func cpuTemperature() (float64, error) {
+	return 0, errUnsupported
+}
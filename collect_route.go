@@ -0,0 +1,36 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// netCounters reads the traffic of all interfaces that are up, except for
+// loopback, from the interface messages of the routing socket. Its if_data is
+// what ifmib(4) and getifaddrs(3) report as well, on macOS it only has 32 bits.
+func netCounters() (map[string]netCounter, error) {
+	rib, err := syscall.RouteRIB(syscall.NET_RT_IFLIST, 0)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseRoutingMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	var counters = map[string]netCounter{}
+	for _, msg := range msgs {
+		var ifm, ok = msg.(*syscall.InterfaceMessage)
+		if !ok || ifm.Header.Flags&syscall.IFF_UP == 0 || ifm.Header.Flags&syscall.IFF_LOOPBACK != 0 {
+			continue
+		}
+		var name = strconv.Itoa(int(ifm.Header.Index))
+		if iface, err := net.InterfaceByIndex(int(ifm.Header.Index)); err == nil {
+			name = iface.Name
+		}
+		counters[name] = netCounter{uint64(ifm.Header.Data.Ibytes), uint64(ifm.Header.Data.Obytes)}
+	}
+	return counters, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// config holds the settings that can be changed without patching the source.
+// It starts out with the defaults below, which are then overlaid by the JSON
+// file at configPath.
+type config struct {
+	// Mode is the display mode gods starts in, "compact" or "expanded"
+	Mode string `json:"mode"`
+	// Output is where the bar goes: "xsetroot" for dwm, "stdout" or "tmux"
+	Output string `json:"output"`
+	// Collector reads the system's numbers, "native" or "gopsutil"
+	Collector string `json:"collector"`
+	// CommandTimeout is how long external commands may run before they
+	// are killed
+	CommandTimeout duration `json:"commandtimeout"`
+	// Hide leaves modules out of the bar until `gods ctl show` brings them
+	// back
+	Hide []string `json:"hide,omitempty"`
+	// Enable turns on modules that are off by default, the carousel turns
+	// on its members by itself
+	Enable []string `json:"enable,omitempty"`
+	// Distro replaces the logo of the distribution
+	Distro        string              `json:"distro,omitempty"`
+	Clocks        []clockConfig       `json:"clocks"`
+	Pomodoro      pomodoroConfig      `json:"pomodoro"`
+	Timer         timerConfig         `json:"timer"`
+	Alarm         alarmsConfig        `json:"alarm"`
+	Break         breakConfig         `json:"break"`
+	Agenda        agendaConfig        `json:"agenda"`
+	Weather       weatherConfig       `json:"weather"`
+	AirQuality    airQualityConfig    `json:"airquality"`
+	Ticker        tickerConfig        `json:"ticker"`
+	Quotes        quotesConfig        `json:"quotes"`
+	Mail          mailConfig          `json:"mail"`
+	Maildir       maildirConfig       `json:"maildir"`
+	GitHub        githubConfig        `json:"github"`
+	Task          taskConfig          `json:"task"`
+	Todo          todoConfig          `json:"todo"`
+	Org           orgConfig           `json:"org"`
+	Matrix        matrixConfig        `json:"matrix"`
+	Slack         slackConfig         `json:"slack"`
+	Telegram      telegramConfig      `json:"telegram"`
+	Transit       transitConfig       `json:"transit"`
+	MQTT          mqttConfig          `json:"mqtt"`
+	HomeAssistant homeAssistantConfig `json:"homeassistant"`
+	HTTP          []httpField         `json:"http"`
+	Files         []fileField         `json:"files"`
+	FIFO          fifoConfig          `json:"fifo"`
+	Webhook       webhookConfig       `json:"webhook"`
+	Clipboard     clipboardConfig     `json:"clipboard"`
+	Keyboard      keyboardConfig      `json:"keyboard"`
+	Peripherals   peripheralsConfig   `json:"peripherals"`
+	SSH           sshConfig           `json:"ssh"`
+	CUPS          cupsConfig          `json:"cups"`
+	Torrent       torrentConfig       `json:"torrent"`
+	Tor           torConfig           `json:"tor"`
+	Click         clickConfig         `json:"click"`
+	Volume        volumeConfig        `json:"volume"`
+	Power         powerConfig         `json:"power"`
+	Carousel      carouselConfig      `json:"carousel"`
+	// Profiles are applied on "ac" and on "battery" power
+	Profiles     map[string]profileConfig `json:"profiles"`
+	Rules        []ruleConfig             `json:"rules"`
+	Notify       notifyConfig             `json:"notify"`
+	Disks        []diskConfig             `json:"disks"`
+	Connectivity connectivityConfig       `json:"connectivity"`
+	History      historyConfig            `json:"history"`
+	StatsD       statsdConfig             `json:"statsd"`
+	Influx       influxConfig             `json:"influx"`
+}
+
+var cfg = config{
+	Mode:           "expanded",
+	Output:         "xsetroot",
+	Collector:      "native",
+	CommandTimeout: duration{10 * time.Second},
+	Clocks: []clockConfig{
+		{Format: dateSeparator + " Mon Jan 02 15:04"},
+	},
+	Pomodoro: pomodoroConfig{
+		Work:      duration{25 * time.Minute},
+		Break:     duration{5 * time.Minute},
+		LongBreak: duration{15 * time.Minute},
+		Rounds:    4,
+	},
+	Timer: timerConfig{
+		Hook: `notify-send "Timer expired" "$TIMER_NAME"`,
+	},
+	Alarm: alarmsConfig{
+		Notice: duration{15 * time.Minute},
+		Hook:   `notify-send -u critical "Alarm" "$ALARM_NAME"`,
+	},
+	Break: breakConfig{
+		Every:  duration{20 * time.Minute},
+		Length: duration{20 * time.Second},
+		Snooze: duration{5 * time.Minute},
+	},
+	Agenda: agendaConfig{
+		Refresh:   duration{15 * time.Minute},
+		Lookahead: duration{12 * time.Hour},
+		Warning:   duration{15 * time.Minute},
+		Urgent:    duration{5 * time.Minute},
+	},
+	Weather: weatherConfig{
+		Provider: "open-meteo",
+		Units:    "metric",
+		Refresh:  duration{20 * time.Minute},
+	},
+	AirQuality: airQualityConfig{
+		Provider: "open-meteo",
+		Refresh:  duration{30 * time.Minute},
+	},
+	Ticker: tickerConfig{
+		Currency: "usd",
+		API:      "https://api.coingecko.com/api/v3",
+		Refresh:  duration{5 * time.Minute},
+	},
+	Quotes: quotesConfig{
+		Provider: "yahoo",
+		Rotate:   duration{10 * time.Second},
+		Refresh:  duration{15 * time.Minute},
+	},
+	Mail: mailConfig{
+		Refresh: duration{5 * time.Minute},
+	},
+	GitHub: githubConfig{
+		Refresh: duration{time.Minute},
+	},
+	Task: taskConfig{
+		Filter:  []string{"status:pending", "due.before:tomorrow"},
+		Urgency: 10,
+	},
+	Todo: todoConfig{
+		File: "~/todo.txt",
+	},
+	Org: orgConfig{
+		Command: `emacs --batch -l ~/.emacs.d/init.el --eval '(org-batch-agenda-csv "a")' 2>/dev/null`,
+		Refresh: duration{10 * time.Minute},
+	},
+	Slack: slackConfig{
+		Refresh: duration{2 * time.Minute},
+	},
+	Transit: transitConfig{
+		Provider: "transport.rest",
+		API:      "https://v6.db.transport.rest",
+		Refresh:  duration{time.Minute},
+	},
+	HomeAssistant: homeAssistantConfig{
+		Refresh: duration{30 * time.Second},
+	},
+	FIFO: fifoConfig{
+		Path: "~/.cache/gods/custom.fifo",
+	},
+	Clipboard: clipboardConfig{
+		Selection: "clipboard",
+		Length:    20,
+	},
+	Keyboard: keyboardConfig{
+		Locks: []string{"caps"},
+	},
+	Peripherals: peripheralsConfig{
+		Warning: 20,
+	},
+	CUPS: cupsConfig{
+		URL: "http://localhost:631",
+	},
+	Tor: torConfig{
+		Control: "127.0.0.1:9051",
+	},
+	Power: powerConfig{
+		Desktop: "hide",
+	},
+	Volume: volumeConfig{
+		Backends: []string{"pipewire", "pulse", "sndio", "alsa"},
+	},
+	Carousel: carouselConfig{
+		Rotate: duration{5 * time.Second},
+	},
+	Notify: notifyConfig{
+		RateLimit:  duration{5 * time.Minute},
+		BatteryLow: 10,
+	},
+	Disks: []diskConfig{
+		{Mount: "/", Thresholds: []int{90, 98}},
+	},
+	Connectivity: connectivityConfig{
+		URL:         "http://connectivitycheck.gstatic.com/generate_204",
+		NotifyAfter: duration{30 * time.Second},
+	},
+	History: historyConfig{
+		MaxSize: 10 << 20,
+		Keep:    3,
+	},
+	StatsD: statsdConfig{
+		Interval: duration{10 * time.Second},
+	},
+	Influx: influxConfig{
+		Interval: duration{10 * time.Second},
+		Batch:    6,
+		Buffer:   8640,
+	},
+}
+
+// duration is a time.Duration written as a string like "25m" or "1h30m" in
+// the config file
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var err error
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// configPath returns the location of the config file following the XDG base
+// directory specification
+func configPath() string {
+	var dir = os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "gods", "config.json")
+}
+
+// statePath returns the location of a file gods keeps its state in across
+// restarts, following the XDG base directory specification
+func statePath(name string) string {
+	var dir = os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "state")
+	}
+	return filepath.Join(dir, "gods", name)
+}
+
+// expandPath replaces a leading ~ by the home directory and expands
+// environment variables
+func expandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = os.Getenv("HOME") + path[1:]
+	}
+	return os.ExpandEnv(path)
+}
+
+// secret runs a command like "pass mail/work" and returns the first line of
+// its output, so no passwords need to be stored in the config file. It has no
+// timeout, as e.g. pinentry waits for the user.
+func secret(command string) (string, error) {
+	var out, err = exec.Command("sh", "-c", command).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", command, err)
+	}
+	return strings.SplitN(strings.TrimRight(string(out), "\n"), "\n", 2)[0], nil
+}
+
+// loadConfig overlays cfg with the config file, a missing file is not an error
+func loadConfig() error {
+	var file, err = os.Open(configPath())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var dec = json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&cfg); err != nil {
+		return fmt.Errorf("%s: %v", configPath(), err)
+	}
+	return nil
+}
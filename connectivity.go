@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	offlineSign = ""
+)
+
+// connectivityConfig sets up the internet connectivity check
+type connectivityConfig struct {
+	// URL has to answer with 204 No Content, like the captive portal
+	// checks of browsers and phones
+	URL string `json:"url"`
+	// NotifyAfter is how long the check has to fail before notifying
+	NotifyAfter duration `json:"notifyafter"`
+}
+
+var connectivity struct {
+	down     time.Time // when the check started failing, zero while online
+	notified bool      // the outage was notified
+}
+
+var connectivityClient = &http.Client{
+	Timeout: 5 * time.Second,
+	// a captive portal redirects, which counts as offline
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// updateConnectivity checks the internet connection and shows how long it
+// has been down. Outages lasting longer than NotifyAfter are notified if
+// notify.modules allows it, as is the end of a notified outage along with how
+// long it lasted. It hides itself while online.
+func updateConnectivity() string {
+	var online = false
+	if resp, err := connectivityClient.Get(cfg.Connectivity.URL); err == nil {
+		resp.Body.Close()
+		online = resp.StatusCode == http.StatusNoContent
+	}
+	if online {
+		record("online", 1)
+	} else {
+		record("online", 0)
+	}
+
+	var now = time.Now()
+	switch {
+	case online && !connectivity.down.IsZero():
+		if connectivity.notified {
+			notify("normal", "Back online", "after "+now.Sub(connectivity.down).Round(time.Second).String())
+		}
+		connectivity.down, connectivity.notified = time.Time{}, false
+	case !online && connectivity.down.IsZero():
+		connectivity.down = now
+	case !online && !connectivity.notified && now.Sub(connectivity.down) >= cfg.Connectivity.NotifyAfter.Duration &&
+		notifyAllowed("connectivity"):
+		connectivity.notified = true
+		notify("critical", "Connection lost", "offline since "+connectivity.down.Format("15:04:05"))
+	}
+
+	if online {
+		return ""
+	}
+	return fmt.Sprintf("%s%s offline %s%s", colorUrgent, offlineSign,
+		clockDuration(now.Sub(connectivity.down)), colorNormal)
+}
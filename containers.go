@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	containerSign = ""
+)
+
+// containerSockets are the API sockets of the container engines, podman
+// serves the same API as docker
+var containerSockets = []string{
+	"/var/run/docker.sock",
+	"/run/podman/podman.sock",
+	"$XDG_RUNTIME_DIR/podman/podman.sock",
+}
+
+// updateContainers shows the number of running containers and how many of
+// them are unhealthy. It hides itself if no engine is running or no container
+// is up.
+func updateContainers() string {
+	var running, unhealthy = 0, 0
+	var seen = map[string]bool{}
+	for _, socket := range containerSockets {
+		// docker.sock is often just a link to the podman socket
+		socket, err := filepath.EvalSymlinks(os.ExpandEnv(socket))
+		if err != nil || seen[socket] {
+			continue
+		}
+		seen[socket] = true
+
+		containers, err := listContainers(socket)
+		if err != nil {
+			continue
+		}
+		for _, container := range containers {
+			running++
+			if strings.Contains(container.Status, "(unhealthy)") {
+				unhealthy++
+			}
+		}
+	}
+
+	if running == 0 {
+		return ""
+	} else if unhealthy > 0 {
+		return fmt.Sprintf("%s %d %s%d unhealthy%s", containerSign, running, colorWarning, unhealthy, colorNormal)
+	}
+	return fmt.Sprintf("%s %d", containerSign, running)
+}
+
+// containerClients talk to the engine behind each socket, they are kept so
+// their idle connections are reused rather than piling up
+var containerClients = map[string]*http.Client{}
+
+// listContainers asks the engine behind socket for its running containers
+func listContainers(socket string) ([]struct{ Status string }, error) {
+	var client, ok = containerClients[socket]
+	if !ok {
+		client = &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+				},
+			},
+		}
+		containerClients[socket] = client
+	}
+	var resp, err = client.Get("http://localhost/containers/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("container API: %s", resp.Status)
+	}
+
+	var containers []struct{ Status string }
+	err = json.NewDecoder(resp.Body).Decode(&containers)
+	return containers, err
+}
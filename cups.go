@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	printerSign = ""
+)
+
+// cupsConfig sets the CUPS server whose queues are shown
+type cupsConfig struct {
+	// URL of the scheduler, http://localhost:631 by default
+	URL string `json:"url"`
+}
+
+var cupsClient = &http.Client{Timeout: 10 * time.Second}
+
+// printer is the part of a CUPS printer's attributes the module shows
+type printer struct {
+	name    string
+	jobs    int
+	stopped bool
+	reasons []string
+}
+
+// IPP constants from RFC 8010/8011 and the CUPS implementation notes
+const (
+	ippGetPrinters     = 0x4002 // CUPS-Get-Printers
+	ippOperationGroup  = 0x01
+	ippEndGroup        = 0x03
+	ippPrinterGroup    = 0x04
+	ippInteger         = 0x21
+	ippEnum            = 0x23
+	ippKeyword         = 0x44
+	ippCharset         = 0x47
+	ippLanguage        = 0x48
+	ippPrinterStopped  = 5
+	ippSuccessfulLimit = 0x00ff
+)
+
+// updateCUPS shows the number of queued print jobs, in the warning color
+// with the reason while a printer with jobs reports an error. It hides itself
+// while the queues are empty or CUPS isn't running.
+func updateCUPS() string {
+	var printers, err = cupsPrinters()
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ""
+	} else if err != nil {
+		return printerSign + " ERR"
+	}
+	var jobs = 0
+	var problems []string
+	for _, p := range printers {
+		jobs += p.jobs
+		if p.jobs == 0 {
+			continue
+		}
+		for _, reason := range p.reasons {
+			if strings.HasSuffix(reason, "-error") {
+				problems = append(problems, strings.TrimSuffix(reason, "-error"))
+			}
+		}
+		if p.stopped && len(problems) == 0 {
+			problems = append(problems, p.name+" stopped")
+		}
+	}
+	if jobs == 0 {
+		return ""
+	} else if len(problems) > 0 {
+		return fmt.Sprintf("%s%s %d %s%s", colorWarning, printerSign, jobs, strings.Join(problems, " "), colorNormal)
+	}
+	return fmt.Sprintf("%s %d", printerSign, jobs)
+}
+
+// cupsPrinters asks the CUPS scheduler for its printers with a
+// CUPS-Get-Printers IPP request
+func cupsPrinters() ([]printer, error) {
+	var req bytes.Buffer
+	req.Write([]byte{2, 0}) // IPP 2.0
+	binary.Write(&req, binary.BigEndian, uint16(ippGetPrinters))
+	binary.Write(&req, binary.BigEndian, uint32(1))
+	req.WriteByte(ippOperationGroup)
+	ippAttribute(&req, ippCharset, "attributes-charset", "utf-8")
+	ippAttribute(&req, ippLanguage, "attributes-natural-language", "en")
+	ippAttribute(&req, ippKeyword, "requested-attributes", "printer-name")
+	// further values of an attribute have an empty name
+	for _, name := range []string{"printer-state", "printer-state-reasons", "queued-job-count"} {
+		ippAttribute(&req, ippKeyword, "", name)
+	}
+	req.WriteByte(ippEndGroup)
+
+	var resp, err = cupsClient.Post(strings.TrimSuffix(cfg.CUPS.URL, "/")+"/", "application/ipp", &req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cups: %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseIPPPrinters(body)
+}
+
+// ippAttribute appends an attribute with a single value to req
+func ippAttribute(req *bytes.Buffer, tag byte, name, value string) {
+	req.WriteByte(tag)
+	binary.Write(req, binary.BigEndian, uint16(len(name)))
+	req.WriteString(name)
+	binary.Write(req, binary.BigEndian, uint16(len(value)))
+	req.WriteString(value)
+}
+
+// parseIPPPrinters reads the printer attribute groups of an IPP response,
+// each of which describes one printer
+func parseIPPPrinters(body []byte) ([]printer, error) {
+	if len(body) < 8 {
+		return nil, fmt.Errorf("cups: short response")
+	}
+	if status := binary.BigEndian.Uint16(body[2:4]); status > ippSuccessfulLimit {
+		return nil, fmt.Errorf("cups: ipp status %#04x", status)
+	}
+	var r = bytes.NewReader(body[8:])
+	var printers []printer
+	var current *printer
+	var name string
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("cups: truncated response")
+		}
+		if tag == ippEndGroup {
+			return printers, nil
+		} else if tag < 0x10 { // the start of a group
+			current = nil
+			if tag == ippPrinterGroup {
+				printers = append(printers, printer{})
+				current = &printers[len(printers)-1]
+			}
+			continue
+		}
+
+		attrName, err := ippValue(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := ippValue(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(attrName) > 0 {
+			name = string(attrName)
+		}
+		if current == nil {
+			continue
+		}
+		switch {
+		case name == "printer-name":
+			current.name = string(value)
+		case name == "printer-state" && tag == ippEnum && len(value) == 4:
+			current.stopped = binary.BigEndian.Uint32(value) == ippPrinterStopped
+		case name == "printer-state-reasons" && string(value) != "none":
+			current.reasons = append(current.reasons, string(value))
+		case name == "queued-job-count" && tag == ippInteger && len(value) == 4:
+			current.jobs = int(binary.BigEndian.Uint32(value))
+		}
+	}
+}
+
+// ippValue reads a name or value prefixed by its length
+func ippValue(r *bytes.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("cups: truncated response")
+	}
+	var value = make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, fmt.Errorf("cups: truncated response")
+	}
+	return value, nil
+}
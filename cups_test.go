@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// ippResponse builds a CUPS-Get-Printers response with the given status and
+// attribute groups following the operation attributes
+func ippResponse(status uint16, groups ...func(*bytes.Buffer)) []byte {
+	var body bytes.Buffer
+	body.Write([]byte{2, 0, byte(status >> 8), byte(status), 0, 0, 0, 1})
+	body.WriteByte(ippOperationGroup)
+	ippAttribute(&body, ippCharset, "attributes-charset", "utf-8")
+	for _, group := range groups {
+		group(&body)
+	}
+	body.WriteByte(ippEndGroup)
+	return body.Bytes()
+}
+
+func ippInt(n byte) string {
+	return string([]byte{0, 0, 0, n})
+}
+
+func TestParseIPPPrinters(t *testing.T) {
+	var office = func(body *bytes.Buffer) {
+		body.WriteByte(ippPrinterGroup)
+		ippAttribute(body, 0x42, "printer-name", "office")
+		ippAttribute(body, ippEnum, "printer-state", ippInt(3))
+		ippAttribute(body, ippKeyword, "printer-state-reasons", "none")
+		ippAttribute(body, ippInteger, "queued-job-count", ippInt(2))
+	}
+	var label = func(body *bytes.Buffer) {
+		body.WriteByte(ippPrinterGroup)
+		ippAttribute(body, 0x42, "printer-name", "label")
+		ippAttribute(body, ippEnum, "printer-state", ippInt(ippPrinterStopped))
+		ippAttribute(body, ippKeyword, "printer-state-reasons", "media-empty")
+		// an additional value of the same attribute comes without a name
+		ippAttribute(body, ippKeyword, "", "paused")
+		ippAttribute(body, ippInteger, "queued-job-count", ippInt(0))
+	}
+
+	var tests = []struct {
+		name string
+		body []byte
+		want []printer
+		fail bool
+	}{
+		{"none", ippResponse(0), nil, false},
+		{"two", ippResponse(0, office, label), []printer{
+			{name: "office", jobs: 2},
+			{name: "label", stopped: true, reasons: []string{"media-empty", "paused"}},
+		}, false},
+		{"not found", ippResponse(0x0406), nil, true},
+		{"short", []byte{2, 0, 0}, nil, true},
+		{"truncated", ippResponse(0, office)[:40], nil, true},
+	}
+	for _, test := range tests {
+		var got, err = parseIPPPrinters(test.body)
+		if (err != nil) != test.fail || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: parseIPPPrinters() = %+v, %v, want %+v", test.name, got, err, test.want)
+		}
+	}
+}
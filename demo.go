@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// demoCycle is how long gods --demo takes to sweep through all values once
+const demoCycle = 2 * time.Minute
+
+// demo is the collector of gods --demo. Its numbers sweep through their
+// ranges, so icons, colors and widths can be tried out without loading the
+// machine or draining the battery: the CPU usage and temperature rise, the
+// network rates go from bytes to megabytes per second and the battery runs
+// down, then charges in the next cycle.
+type demo struct {
+	start time.Time
+
+	mu     sync.Mutex
+	last   time.Time
+	rx, tx float64
+}
+
+// startDemo replaces the collector and the volume by the demo and keeps the
+// made up numbers from running rules or sending notifications
+func startDemo() {
+	var d = &demo{start: time.Now()}
+	sys = d
+	volumeBackends["demo"] = volumeBackend{d.readVolume, func(string) error { return nil }}
+	cfg.Volume.Backends = []string{"demo"}
+	cfg.Rules = nil
+	cfg.Notify.Modules = nil
+}
+
+// demoPhase is how far the current cycle has progressed, from 0 to 1, and
+// whether it is an odd one
+func demoPhase(start time.Time) (float64, bool) {
+	var elapsed = time.Since(start)
+	return float64(elapsed%demoCycle) / float64(demoCycle), elapsed/demoCycle%2 == 1
+}
+
+func (d *demo) cpuUsage() (float64, error) {
+	var phase, _ = demoPhase(d.start)
+	return phase * 100, nil
+}
+
+func (d *demo) memoryUsage() (used, total float64, err error) {
+	var phase, _ = demoPhase(d.start)
+	total = 16 << 20 // KiB
+	return total * (0.1 + 0.9*phase), total, nil
+}
+
+func (d *demo) readBattery() (battery, error) {
+	var phase, odd = demoPhase(d.start)
+	if odd {
+		return battery{now: phase * 100, full: 100, plugged: true}, nil
+	}
+	return battery{now: (1 - phase) * 100, full: 100}, nil
+}
+
+// netCounters adds what the interface transferred since the last call at
+// rates from 1 B/s to 100 MiB/s, the upload going the opposite way
+func (d *demo) netCounters() (map[string]netCounter, error) {
+	var phase, _ = demoPhase(d.start)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var now = time.Now()
+	if !d.last.IsZero() {
+		var seconds = now.Sub(d.last).Seconds()
+		d.rx += math.Pow(100<<20, phase) * seconds
+		d.tx += math.Pow(100<<20, 1-phase) * seconds
+	}
+	d.last = now
+	return map[string]netCounter{"demo0": {uint64(d.rx), uint64(d.tx)}}, nil
+}
+
+func (d *demo) cpuTemperature() (float64, error) {
+	var phase, _ = demoPhase(d.start)
+	return 30 + 70*phase, nil
+}
+
+// readVolume turns the volume up over a cycle and mutes it for the last
+// tenth of it
+func (d *demo) readVolume() (percent int, muted bool, err error) {
+	var phase, _ = demoPhase(d.start)
+	return int(phase * 100), phase >= 0.9, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	diskSign = ""
+)
+
+// diskConfig is a filesystem watched for running full
+type diskConfig struct {
+	Mount string `json:"mount"`
+	// Thresholds are the usage percentages alerted at, each once
+	Thresholds []int `json:"thresholds"`
+}
+
+// diskLevels is the highest threshold each mount was alerted at
+var diskLevels = map[string]int{}
+
+// updateDisks shows the usage of the monitored filesystems above their
+// first threshold and alerts once per threshold crossed. It hides itself
+// while there is enough space everywhere.
+func updateDisks() string {
+	var fields []string
+	for _, disk := range cfg.Disks {
+		var used, err = diskUsage(disk.Mount)
+		if err != nil {
+			fields = append(fields, disk.Mount+" ERR")
+			continue
+		}
+		record("disk:"+disk.Mount, float64(used))
+
+		var level = 0
+		for _, threshold := range disk.Thresholds {
+			if used >= threshold && threshold > level {
+				level = threshold
+			}
+		}
+		if level > diskLevels[disk.Mount] && notifyAllowed("disk") {
+			notify("critical", "Disk almost full", fmt.Sprintf("%s is %d%% full", disk.Mount, used))
+		}
+		diskLevels[disk.Mount] = level
+		if level > 0 {
+			fields = append(fields, fmt.Sprintf("%s%s %d%%%s", colorWarning, disk.Mount, used, colorNormal))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return diskSign + " " + strings.Join(fields, " ")
+}
+
+// diskUsage returns how full the filesystem mounted at mount is in percent,
+// df's POSIX output is the same on every system
+func diskUsage(mount string) (int, error) {
+	var out, err = timedCommand("df", "-P", "-k", mount).Output()
+	if err != nil {
+		return 0, err
+	}
+	var lines = strings.Split(strings.TrimSpace(string(out)), "\n")
+	var fields = strings.Fields(lines[len(lines)-1])
+	if len(fields) < 6 {
+		return 0, fmt.Errorf("df: unexpected output")
+	}
+	// Filesystem 1024-blocks Used Available Capacity Mounted on
+	return strconv.Atoi(strings.TrimSuffix(fields[4], "%"))
+}
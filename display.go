@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// display is the current render profile of the modules, compact for small
+// monitors or expanded with more detail for large ones
+var display struct {
+	sync.Mutex
+	mode string
+}
+
+// expanded reports whether modules show their detailed layout
+func expanded() bool {
+	display.Lock()
+	defer display.Unlock()
+	if display.mode == "" {
+		return cfg.Mode == "expanded"
+	}
+	return display.mode == "expanded"
+}
+
+// setMode switches all modules to the named display mode
+func setMode(mode string) {
+	display.Lock()
+	display.mode = mode
+	display.Unlock()
+	for _, m := range modules {
+		refresh(m.name)
+	}
+}
+
+// modeCommand switches between the compact and expanded layouts
+func modeCommand(args []string) (string, error) {
+	var mode = strings.Join(args, " ")
+	switch mode {
+	case "toggle":
+		mode = "expanded"
+		if expanded() {
+			mode = "compact"
+		}
+	case "compact", "expanded":
+	default:
+		return "", fmt.Errorf("usage: mode toggle|compact|expanded")
+	}
+	setMode(mode)
+	return "", nil
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package main
+
+// watchModeSignal does nothing without SIGUSR1, ctl mode toggle still works
+func watchModeSignal() {}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchModeSignal toggles the display mode on SIGUSR1, e.g. from a hotkey
+// daemon with `pkill -USR1 gods`
+func watchModeSignal() {
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	for range signals {
+		modeCommand([]string{"toggle"})
+	}
+}
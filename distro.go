@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strings"
+)
+
+const (
+	linuxSign = ""
+)
+
+// distroSigns maps the ID of /etc/os-release to the logos of Nerd Fonts
+var distroSigns = map[string]string{
+	"alpine":     "",
+	"arch":       "",
+	"centos":     "",
+	"debian":     "",
+	"devuan":     "",
+	"elementary": "",
+	"fedora":     "",
+	"freebsd":    "",
+	"gentoo":     "",
+	"linuxmint":  "",
+	"mageia":     "",
+	"manjaro":    "",
+	"nixos":      "",
+	"openbsd":    "",
+	"opensuse":   "",
+	"raspbian":   "",
+	"rhel":       "",
+	"slackware":  "",
+	"ubuntu":     "",
+	"void":       "",
+}
+
+// updateDistro shows the logo of the distribution, found by the ID in
+// /etc/os-release or, failing that, one of the IDs in ID_LIKE. cfg.Distro
+// replaces it.
+func updateDistro() string {
+	if cfg.Distro != "" {
+		return cfg.Distro
+	}
+	if sign, ok := distroSigns[runtime.GOOS]; ok {
+		return sign
+	}
+	var release = osRelease()
+	// e.g. ID=opensuse-tumbleweed
+	var ids = append([]string{release["ID"]}, strings.Fields(release["ID_LIKE"])...)
+	for _, id := range ids {
+		if sign, ok := distroSigns[strings.SplitN(id, "-", 2)[0]]; ok {
+			return sign
+		}
+	}
+	return linuxSign
+}
+
+// osRelease reads the variables of os-release(5), which may be quoted
+func osRelease() map[string]string {
+	var content, err = ioutil.ReadFile("/etc/os-release")
+	if err != nil {
+		content, _ = ioutil.ReadFile("/usr/lib/os-release")
+	}
+	var release = map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		var kv = strings.SplitN(line, "=", 2)
+		if len(kv) == 2 {
+			release[kv[0]] = strings.Trim(kv[1], `"'`)
+		}
+	}
+	return release
+}
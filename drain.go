@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// drainWindow is how far back the discharge rate is averaged over
+const drainWindow = 15 * time.Minute
+
+// energySample is the battery energy at a point in time
+type energySample struct {
+	at     time.Time
+	energy float64
+}
+
+var drain struct {
+	sync.Mutex
+	samples []energySample
+	seeded  bool
+}
+
+// recordEnergy adds the battery's energy to the samples the discharge rate
+// is computed from. Samples taken while charging are dropped.
+func recordEnergy(now time.Time, energy float64, charging bool) {
+	record("battery_energy", energy)
+	drain.Lock()
+	defer drain.Unlock()
+	if !drain.seeded {
+		drain.samples = loadEnergyHistory(now)
+		drain.seeded = true
+	}
+	if charging {
+		drain.samples = nil
+		return
+	}
+	// a rising energy means the charger was plugged in meanwhile
+	if n := len(drain.samples); n > 0 && energy > drain.samples[n-1].energy {
+		drain.samples = nil
+	}
+	drain.samples = append(drain.samples, energySample{now, energy})
+	for len(drain.samples) > 0 && now.Sub(drain.samples[0].at) > drainWindow {
+		drain.samples = drain.samples[1:]
+	}
+}
+
+// timeRemaining estimates how long the battery lasts from the average drain
+// of the last minutes. It is only known after a few minutes on battery.
+func timeRemaining() (time.Duration, bool) {
+	drain.Lock()
+	defer drain.Unlock()
+	if len(drain.samples) < 2 {
+		return 0, false
+	}
+	var first, last = drain.samples[0], drain.samples[len(drain.samples)-1]
+	var span = last.at.Sub(first.at)
+	if span < 5*time.Minute || first.energy <= last.energy {
+		return 0, false
+	}
+	var perSecond = (first.energy - last.energy) / span.Seconds()
+	return time.Duration(last.energy/perSecond) * time.Second, true
+}
+
+// loadEnergyHistory reads the recent samples from the metrics history, so a
+// restart doesn't start the estimate over
+func loadEnergyHistory(now time.Time) []energySample {
+	var file, err = os.Open(historyFile())
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var samples []energySample
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var fields = strings.Split(scanner.Text(), ",")
+		if len(fields) != 3 || fields[1] != "battery_energy" {
+			continue
+		}
+		var at, errTime = time.Parse(time.RFC3339, fields[0])
+		var energy, errEnergy = strconv.ParseFloat(fields[2], 64)
+		if errTime != nil || errEnergy != nil || now.Sub(at) > drainWindow {
+			continue
+		}
+		if n := len(samples); n > 0 && energy > samples[n-1].energy {
+			samples = nil
+		}
+		samples = append(samples, energySample{at, energy})
+	}
+	return samples
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	entropySign = ""
+
+	entropyLow = 1000 // bits, below this the pool is shown as exhausted
+)
+
+// updateEntropy shows the entropy available in the kernel's input pool. Since
+// Linux 5.18 the pool always reports full, so there it only shows whether the
+// CSPRNG has been seeded yet.
+func updateEntropy() string {
+	var avail, err = readProcInt(procPath("/proc/sys/kernel/random/entropy_avail"))
+	if err != nil {
+		return entropySign + " ERR"
+	}
+	poolsize, err := readProcInt(procPath("/proc/sys/kernel/random/poolsize"))
+	if err != nil {
+		return entropySign + " ERR"
+	}
+
+	if poolsize <= 256 {
+		if !crngReady() {
+			return colorWarning + entropySign + " unseeded" + colorNormal
+		}
+		return entropySign + " ok"
+	} else if avail < entropyLow {
+		return fmt.Sprintf("%s%s %d%s", colorWarning, entropySign, avail, colorNormal)
+	}
+	return fmt.Sprintf("%s %d", entropySign, avail)
+}
+
+// readProcInt reads a file holding a single integer
+func readProcInt(path string) (int, error) {
+	var content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"syscall"
+)
+
+// crngReady reads /dev/random without blocking, which since Linux 5.6 only
+// fails with EAGAIN while the CSPRNG has not been seeded yet
+func crngReady() bool {
+	var fd, err = syscall.Open("/dev/random", syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return false
+	}
+	defer syscall.Close(fd)
+	var buf [1]byte
+	_, err = syscall.Read(fd, buf[:])
+	return err != syscall.EAGAIN
+}
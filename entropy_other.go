@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// crngReady is only meaningful on linux
+func crngReady() bool {
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	fail2banSign = ""
+)
+
+// updateFail2ban shows the number of addresses fail2ban currently bans, per
+// jail in the expanded layout. It hides itself while nothing is banned.
+// fail2ban-client needs access to the fail2ban socket, which usually takes
+// root or a group allowed to read it.
+func updateFail2ban() string {
+	var out, err = output(nil, "fail2ban-client", "status")
+	if err != nil {
+		return fail2banSign + " ERR"
+	}
+	var jails = strings.Split(fail2banField(out, "Jail list"), ",")
+
+	var total = 0
+	var fields []string
+	for _, jail := range jails {
+		jail = strings.TrimSpace(jail)
+		if jail == "" {
+			continue
+		}
+		out, err := output(nil, "fail2ban-client", "status", jail)
+		if err != nil {
+			return fail2banSign + " ERR"
+		}
+		var banned, _ = strconv.Atoi(fail2banField(out, "Currently banned"))
+		if banned > 0 {
+			total += banned
+			fields = append(fields, fmt.Sprintf("%s %d", jail, banned))
+		}
+	}
+
+	if total == 0 {
+		return ""
+	} else if expanded() {
+		return fail2banSign + " " + strings.Join(fields, " ")
+	}
+	return fmt.Sprintf("%s %d", fail2banSign, total)
+}
+
+// fail2banField returns the value of a field in the tree fail2ban-client
+// prints, like "`- Jail list:	sshd, nginx"
+func fail2banField(out []byte, name string) string {
+	for _, line := range strings.Split(string(out), "\n") {
+		var i = strings.Index(line, name+":")
+		if i >= 0 {
+			return strings.TrimSpace(line[i+len(name)+1:])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// fallback uses the first of an ordered list of backends that works on this
+// machine and remembers it, so the others aren't tried on every update. Once
+// the remembered one fails, the list is tried again.
+type fallback struct {
+	sync.Mutex
+	chosen string
+}
+
+// fallbacks are listed by `gods config dump` with the backend they chose
+var fallbacks = map[string]*fallback{
+	"volume":   &volumeFallback,
+	"sessions": &sessionFallback,
+}
+
+// current returns the remembered backend, "" if none works so far
+func (f *fallback) current() string {
+	f.Lock()
+	defer f.Unlock()
+	return f.chosen
+}
+
+// try calls use with the remembered backend, or else with each of names in
+// turn until one succeeds. It returns the last error if none does.
+func (f *fallback) try(names []string, use func(name string) error) error {
+	f.Lock()
+	defer f.Unlock()
+	var failed = ""
+	if f.chosen != "" {
+		var err = use(f.chosen)
+		if err == nil {
+			return nil
+		}
+		failed, f.chosen = f.chosen, ""
+	}
+	var err = errors.New("no backend to try")
+	for _, name := range names {
+		if name == failed {
+			continue
+		}
+		if err = use(name); err == nil {
+			f.chosen = name
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+)
+
+// fifoConfig is the named pipe external scripts write to, created with e.g.
+// `mkfifo ~/.cache/gods/custom.fifo`
+type fifoConfig struct {
+	Path string `json:"path"`
+}
+
+var fifo struct {
+	sync.Mutex
+	text string
+}
+
+// updateFIFO shows the last line written to the FIFO, an empty line clears it
+func updateFIFO() string {
+	fifo.Lock()
+	defer fifo.Unlock()
+	return fifo.text
+}
+
+// watchFIFO reads the lines written to the FIFO. Opening it blocks until a
+// writer shows up, it is opened again once all writers are gone.
+func watchFIFO() {
+	var path = expandPath(cfg.FIFO.Path)
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		return
+	}
+	for {
+		var file, err = os.Open(path)
+		if err != nil {
+			return
+		}
+		var scanner = bufio.NewScanner(file)
+		for scanner.Scan() {
+			fifo.Lock()
+			fifo.text = strings.TrimSpace(scanner.Text())
+			fifo.Unlock()
+			refresh("fifo")
+		}
+		file.Close()
+	}
+}
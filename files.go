@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// fileField shows the content of a file, which is read again as soon as it
+// is written
+type fileField struct {
+	Path string `json:"path"`
+	// Regexp picks the first match from the content, or its first group if
+	// it has one
+	Regexp string `json:"regexp,omitempty"`
+	// Template renders the content given as {{.}}. An empty result hides
+	// the field.
+	Template string `json:"template,omitempty"`
+}
+
+// fileState are the compiled regexp and template of a field
+type fileState struct {
+	re   *regexp.Regexp
+	tmpl *template.Template
+	err  error
+}
+
+var fileStates = map[int]*fileState{}
+
+// updateFiles shows the content of all configured files, missing files are
+// hidden
+func updateFiles() string {
+	var fields []string
+	for i, field := range cfg.Files {
+		var state, ok = fileStates[i]
+		if !ok {
+			state = compileFileField(field)
+			fileStates[i] = state
+		}
+		var content, err = ioutil.ReadFile(expandPath(field.Path))
+		if err != nil {
+			continue
+		}
+
+		var text = strings.TrimSpace(string(content))
+		if state.err != nil {
+			text = "ERR"
+		} else if state.re != nil {
+			var match = state.re.FindStringSubmatch(text)
+			switch {
+			case match == nil:
+				text = ""
+			case len(match) > 1:
+				text = match[1]
+			default:
+				text = match[0]
+			}
+		}
+		if state.tmpl != nil && state.err == nil {
+			var out bytes.Buffer
+			if err := state.tmpl.Execute(&out, text); err != nil {
+				text = "ERR"
+			} else {
+				text = strings.TrimSpace(out.String())
+			}
+		}
+		if text != "" {
+			fields = append(fields, text)
+		}
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// compileFileField prepares the regexp and template of a field
+func compileFileField(field fileField) *fileState {
+	var state = &fileState{}
+	if field.Regexp != "" {
+		state.re, state.err = regexp.Compile(field.Regexp)
+	}
+	if field.Template != "" && state.err == nil {
+		state.tmpl, state.err = template.New(field.Path).Parse(field.Template)
+	}
+	return state
+}
+
+// watchFiles shows the new content as soon as one of the files is written
+func watchFiles() {
+	var paths []string
+	for _, field := range cfg.Files {
+		paths = append(paths, expandPath(field.Path))
+	}
+	if len(paths) > 0 {
+		watch(paths, func() { refresh("files") })
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	locateRefresh = 30 * time.Minute // how long a determined location is used
+)
+
+// whereAmI is GeoClue's demo client, shipped in different places by the
+// distributions
+var whereAmI = []string{
+	"/usr/lib/geoclue-2.0/demos/where-am-i",
+	"/usr/libexec/geoclue-2.0/demos/where-am-i",
+}
+
+// located is the last automatically determined location, shared by the
+// weather and air quality modules
+var located struct {
+	sync.Mutex
+	lat, lon float64
+	at       time.Time
+}
+
+// locate determines the current location with GeoClue or, if that is not
+// available, from the public IP address
+func locate() (float64, float64, error) {
+	located.Lock()
+	defer located.Unlock()
+	if time.Since(located.at) < locateRefresh {
+		return located.lat, located.lon, nil
+	}
+	var lat, lon, err = locateGeoClue()
+	if err != nil {
+		lat, lon, err = locateIP()
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	located.lat, located.lon, located.at = lat, lon, time.Now()
+	return lat, lon, nil
+}
+
+// locateGeoClue asks GeoClue through its where-am-i client, which prints
+// e.g. "Latitude:    48.137154°"
+func locateGeoClue() (float64, float64, error) {
+	for _, path := range whereAmI {
+		var out, err = timedCommand(path, "--timeout", "10").Output()
+		if err != nil {
+			continue
+		}
+		var lat, lon = 0.0, 0.0
+		var found = 0
+		for _, line := range strings.Split(string(out), "\n") {
+			var fields = strings.Fields(strings.Replace(line, "°", "", 1))
+			if len(fields) != 2 {
+				continue
+			}
+			switch fields[0] {
+			case "Latitude:":
+				if _, err := fmt.Sscan(fields[1], &lat); err == nil {
+					found |= 1
+				}
+			case "Longitude:":
+				if _, err := fmt.Sscan(fields[1], &lon); err == nil {
+					found |= 2
+				}
+			}
+		}
+		if found == 3 {
+			return lat, lon, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("geoclue not available")
+}
+
+// locateIP looks up the location of the public IP address
+func locateIP() (float64, float64, error) {
+	var data struct{ Loc string }
+	if err := getJSON(weatherClient, "https://ipinfo.io/json", &data); err != nil {
+		return 0, 0, err
+	}
+	var lat, lon = 0.0, 0.0
+	if _, err := fmt.Sscanf(data.Loc, "%f,%f", &lat, &lon); err != nil {
+		return 0, 0, fmt.Errorf("ipinfo.io: invalid location %q", data.Loc)
+	}
+	return lat, lon, nil
+}
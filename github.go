@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	githubSign = ""
+)
+
+// githubConfig holds the access to the GitHub notifications
+type githubConfig struct {
+	// Token is a personal access token with the notifications scope
+	Token string `json:"token,omitempty" secret:"true"`
+	// TokenCommand prints the token instead, e.g. "pass github/token"
+	TokenCommand string `json:"tokencommand,omitempty"`
+	// Split shows review requests and mentions separately
+	Split   bool     `json:"split,omitempty"`
+	Refresh duration `json:"refresh"`
+}
+
+// githubCounts are the unread notifications by reason
+type githubCounts struct {
+	total, reviews, mentions int
+}
+
+var github struct {
+	counts  githubCounts
+	etag    string
+	fetched bool
+	poll    poller
+}
+
+var githubNext = regexp.MustCompile(`<([^>]+)>; rel="next"`)
+
+// updateGitHub shows the number of unread notifications and hides itself if
+// there are none. Polling follows the interval GitHub asks for and the
+// response is only transferred again if it changed.
+func updateGitHub() string {
+	if cfg.GitHub.Token == "" && cfg.GitHub.TokenCommand == "" {
+		return ""
+	}
+	if github.poll.refresh == 0 {
+		github.poll.refresh = cfg.GitHub.Refresh.Duration
+	}
+	if github.poll.due() {
+		github.poll.done(fetchGitHub())
+	}
+	if !github.fetched {
+		return githubSign + " ERR"
+	}
+
+	var counts = github.counts
+	if counts.total == 0 {
+		return ""
+	} else if cfg.GitHub.Split {
+		return fmt.Sprintf("%s %d r%d @%d", githubSign, counts.total, counts.reviews, counts.mentions)
+	}
+	return fmt.Sprintf("%s %d", githubSign, counts.total)
+}
+
+// fetchGitHub counts the unread notifications on all pages. It also adjusts
+// the polling interval to the X-Poll-Interval and rate limit headers.
+func fetchGitHub() error {
+	var token = cfg.GitHub.Token
+	if cfg.GitHub.TokenCommand != "" {
+		var err error
+		if token, err = secret(cfg.GitHub.TokenCommand); err != nil {
+			return err
+		}
+	}
+
+	var counts githubCounts
+	for url, first := "https://api.github.com/notifications?per_page=100", true; url != ""; first = false {
+		var notModified bool
+		var err error
+		if url, notModified, err = fetchGitHubPage(url, token, first, &counts); err != nil {
+			return err
+		} else if notModified {
+			return nil
+		}
+	}
+	github.counts, github.fetched = counts, true
+	return nil
+}
+
+// fetchGitHubPage adds the unread notifications on one page to counts and
+// returns the URL of the next page, if any, and whether the first page is
+// unchanged since the last poll
+func fetchGitHubPage(url, token string, first bool, counts *githubCounts) (string, bool, error) {
+	var req, _ = http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if first && github.etag != "" {
+		req.Header.Set("If-None-Match", github.etag)
+	}
+	var resp, err = weatherClient.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+
+	if first {
+		github.poll.refresh = cfg.GitHub.Refresh.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("X-Poll-Interval")); err == nil {
+			if interval := time.Duration(secs) * time.Second; interval > github.poll.refresh {
+				github.poll.refresh = interval
+			}
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			github.poll.refresh = time.Until(time.Unix(reset, 0))
+		}
+	}
+	if first && resp.StatusCode == http.StatusNotModified {
+		return "", true, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("github: %s", resp.Status)
+	}
+
+	var notifications []struct {
+		Reason string
+		Unread bool
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&notifications); err != nil {
+		return "", false, err
+	}
+	for _, n := range notifications {
+		if !n.Unread {
+			continue
+		}
+		counts.total++
+		switch n.Reason {
+		case "review_requested":
+			counts.reviews++
+		case "mention", "team_mention":
+			counts.mentions++
+		}
+	}
+
+	if first {
+		github.etag = resp.Header.Get("ETag")
+	}
+	if next := githubNext.FindStringSubmatch(resp.Header.Get("Link")); next != nil {
+		return next[1], false, nil
+	}
+	return "", false, nil
+}
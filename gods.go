@@ -11,16 +11,14 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
-	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
-	"regexp"
 )
 
 const (
@@ -56,6 +54,12 @@ const (
 
 	keyboardSign = ""
 
+	// statuscolors escapes, the colors are defined in dwm's config.h
+	colorNormal  = "\x01"
+	colorGood    = "\x02"
+	colorWarning = "\x03"
+	colorUrgent  = "\x04"
+
 	floatSeparator = "."
 	dateSeparator  = ""
 	fieldSeparator = " "
@@ -70,8 +74,7 @@ var (
 		"wlp4s0:": {},
 	}
 	cores = runtime.NumCPU() // count of cores to scale cpu usage
-	rxOld = 0
-	txOld = 0
+	netOld map[string]netCounter // counters of the previous updateNetUse
 )
 
 // fixed builds a fixed width string with given pre- and fitting suffix
@@ -109,23 +112,22 @@ func fixed(pre string, rate int) string {
 
 // updateNetUse reads current transfer rates of certain network interfaces
 func updateNetUse() string {
-	file, err := os.Open("/proc/net/dev")
+	var counters, err = sys.netCounters()
 	if err != nil {
 		return netReceivedSign + " ERR " + netTransmittedSign + " ERR"
 	}
-	defer file.Close()
-
-	var void = 0 // target for unused values
-	var dev, rx, tx, rxNow, txNow = "", 0, 0, 0, 0
-	var scanner = bufio.NewScanner(file)
-	for scanner.Scan() {
-		_, err = fmt.Sscanf(scanner.Text(), "%s %d %d %d %d %d %d %d %d %d",
-			&dev, &rx, &void, &void, &void, &void, &void, &void, &void, &tx)
-		if _, ok := netDevs[dev]; ok {
-			rxNow += rx
-			txNow += tx
+	var rx, tx, rxTotal, txTotal uint64
+	for dev, now := range counters {
+		rxTotal += now.rx
+		txTotal += now.tx
+		// the first sample of an interface has nothing to compare to, and
+		// counters start over when a driver is reloaded or wrap around
+		if old, ok := netOld[dev]; ok && now.rx >= old.rx && now.tx >= old.tx {
+			rx += now.rx - old.rx
+			tx += now.tx - old.tx
 		}
 	}
+	netOld = counters
 
 	// attempt to read avgping file
 	// add the following to your crontab:
@@ -139,12 +141,17 @@ func updateNetUse() string {
 		if err != nil {
 			ping = " " + pingSign + "0.0ms"
 		} else {
+			record("ping", pingAvg)
 			ping = fmt.Sprintf(" %s %dms", pingSign, int(pingAvg))
 		}
 	}
+	if !expanded() {
+		ping = ""
+	}
 
-	defer func() { rxOld, txOld = rxNow, txNow }()
-	return fmt.Sprintf("%s %s%s", fixed(netReceivedSign, rxNow-rxOld), fixed(netTransmittedSign, txNow-txOld), ping)
+	record("net_rx", float64(rxTotal))
+	record("net_tx", float64(txTotal))
+	return fmt.Sprintf("%s %s%s", fixed(netReceivedSign, int(rx)), fixed(netTransmittedSign, int(tx)), ping)
 }
 
 // colored surrounds the percentage with color escapes if it is >= 70
@@ -157,54 +164,39 @@ func colored(icon string, percentage int) string {
 	return fmt.Sprintf("%s%3d", icon, percentage)
 }
 
+// powerConfig sets how the power modules behave
+type powerConfig struct {
+	// Desktop is what the power module shows on machines without a battery:
+	// nothing ("hide") or the plug ("plugged"). powertime hides either way.
+	Desktop string `json:"desktop"`
+}
+
 // updatePower reads the current battery and power plug status
 func updatePower() string {
-	const powerSupply = "/sys/class/power_supply/"
-	var enFull, enNow, enPerc int = 0, 0, 0
-	var plugged, err = ioutil.ReadFile(powerSupply + "AC/online")
-	if err != nil {
-		return "|ERR"
-	}
-	batts, err := ioutil.ReadDir(powerSupply)
-	if err != nil {
-		return "|ERR"
-	}
-
-	readval := func(name, field string) int {
-		var path = powerSupply + name + "/"
-		var file []byte
-		if tmp, err := ioutil.ReadFile(path + "energy_" + field); err == nil {
-			file = tmp
-		} else if tmp, err := ioutil.ReadFile(path + "charge_" + field); err == nil {
-			file = tmp
-		} else {
-			return 0
-		}
-
-		if ret, err := strconv.Atoi(strings.TrimSpace(string(file))); err == nil {
-			return ret
-		}
-		return 0
-	}
-
-	for _, batt := range batts {
-		name := batt.Name()
-		if !strings.HasPrefix(name, "BAT") {
-			continue
+	var batt, err = sys.readBattery()
+	if err == errNoBattery {
+		setPowerProfile("ac")
+		if cfg.Power.Desktop == "plugged" {
+			return pluggedSign
 		}
-
-		enFull += readval(name, "full")
-		enNow += readval(name, "now")
-	}
-
-	if enFull == 0 { // Battery found but no readable full file.
+		return ""
+	} else if err != nil {
 		return "|ERR"
 	}
+	if batt.plugged {
+		setPowerProfile("ac")
+	} else {
+		setPowerProfile("battery")
+	}
 
-	enPerc = enNow * 100 / enFull
+	var enPerc = int(batt.now * 100 / batt.full)
+	record("battery", float64(enPerc))
+	recordEnergy(time.Now(), batt.now, batt.plugged)
+	notifyWhen("power", !batt.plugged && enPerc <= cfg.Notify.BatteryLow,
+		"critical", "Battery low", fmt.Sprintf("%d%% left", enPerc))
 	var icon = batterySign100
 	var icon2 = ""
-	if string(plugged) == "1\n" {
+	if batt.plugged {
 		icon = pluggedSign
 		if enPerc <= 98 {
 			icon2 = ""
@@ -224,66 +216,68 @@ func updatePower() string {
 	return fmt.Sprintf("%s%s%3d%%", icon, icon2, enPerc)
 }
 
-// updatePowerTime runs acpi -b to get the time to deplete/full charge the battery
+// updatePowerTime shows the time until the battery is empty, estimated from
+// the drain of the last minutes. Until that is known and while charging it
+// runs acpi -b to get the time to deplete/full charge the battery. It hides
+// itself without a battery.
 func updatePowerTime() string {
-	var out, err = exec.Command("acpi", "-b").Output()
-	if err != nil {
-		return "unknown"
-	}
-	acpi := string(out)
-	timeRx := regexp.MustCompile(`.*(\d\d:\d\d:\d\d).*`)
-	acpiMatch := timeRx.FindStringSubmatch(acpi)
-	if len(acpiMatch) == 1 {
-		return "unknown"
-	} else {
-		return acpiMatch[1][0:5]
+	if _, err := sys.readBattery(); err == errNoBattery {
+		return ""
+	}
+	var remaining, ok = timeRemaining()
+	if !ok {
+		var err error
+		if remaining, err = acpiRemaining(); err != nil {
+			return "unknown"
+		}
 	}
+	var minutes = int(remaining.Minutes())
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
 }
 
-// updateCPUUse reads the last minute sysload and scales it to the core count
-func updateCPUUse() string {
-	var load float32
-	var loadavg, err = ioutil.ReadFile("/proc/loadavg")
+// acpiRemaining returns the first time to deplete or fully charge a battery
+// that acpi -b reports. Batteries without an estimate, e.g. while charging at
+// zero rate, leave out the time:
+//
+//	Battery 0: Discharging, 85%, 02:10:33 remaining
+//	Battery 1: Unknown, 100%
+func acpiRemaining() (time.Duration, error) {
+	var cmd = timedCommand("acpi", "-b")
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var out, err = cmd.Output()
 	if err != nil {
-		return cpuSign + "ERR"
+		return 0, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		for _, field := range strings.Fields(line) {
+			var h, m, s int
+			if n, _ := fmt.Sscanf(field, "%d:%d:%d", &h, &m, &s); n == 3 {
+				return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second, nil
+			}
+		}
 	}
-	_, err = fmt.Sscanf(string(loadavg), "%f", &load)
+	return 0, fmt.Errorf("acpi: no time remaining")
+}
+
+// updateCPUUse shows the share of the cores in use
+func updateCPUUse() string {
+	var usage, err = sys.cpuUsage()
 	if err != nil {
 		return cpuSign + "ERR"
 	}
-	return fmt.Sprintf("%s%3d%%", cpuSign, int(load*100.0/float32(cores)))
+	record("cpu", usage)
+	return fmt.Sprintf("%s%3d%%", cpuSign, int(usage))
 }
 
-// updateMemUse reads the memory used by applications and scales to [0, 100]
+// updateMemUse shows the memory used by applications, scaled to [0, 100]
 func updateMemUse() string {
-	var file, err = os.Open("/proc/meminfo")
+	var used, total, err = sys.memoryUsage()
 	if err != nil {
 		return memSign + "ERR"
 	}
-	defer file.Close()
-
-	// done must equal the flag combination (0001 | 0010 | 0100 | 1000) = 15
-	var used, total, done = 0.0, 0.0, 0
-	for info := bufio.NewScanner(file); done != 15 && info.Scan(); {
-		var prop, val = "", 0.0
-		if _, err = fmt.Sscanf(info.Text(), "%s %f", &prop, &val); err != nil {
-			return memSign + "ERR"
-		}
-		switch prop {
-		case "MemTotal:":
-			total = val
-			used += val
-			done |= 1
-		case "MemFree:":
-			used -= val
-			done |= 2
-		case "Buffers:":
-			used -= val
-			done |= 4
-		case "Cached:":
-			used -= val
-			done |= 8
-		}
+	record("mem", used*100/total)
+	if !expanded() {
+		return fmt.Sprintf("%s%3d%%", memSign, int(used*100/total))
 	}
 	used = used / 1024 / 1024
 	total = total / 1024 / 1024
@@ -291,88 +285,83 @@ func updateMemUse() string {
 }
 
 func updateVolume() string {
-	var out, err = exec.Command("pacmd", "list-sinks").Output()
+	var volume, muted, err = readVolume()
 	if err != nil {
 		return mutedSign + " ERR"
 	}
 	var sign = volSign
-	pacmd := string(out)
-	mutedRx := regexp.MustCompile(`(?s).*volume: front-left: .* (\d*%) /.*front-right: .* (\d*%).*muted: (yes|no).*`)
-	pacmdMatch := mutedRx.FindStringSubmatch(pacmd)
-	if pacmdMatch[3] == "yes" {
+	if muted {
 		sign = mutedSign
 	}
-	return sign + " " + pacmdMatch[1]
+	return fmt.Sprintf("%s %d%%", sign, volume)
 }
 
+// updateWifi shows the link quality of the best connected wireless interface
 func updateWifi() string {
-	var out, err = exec.Command("awk", "NR==3 {printf \"%3.0f\" ,($3/70)*100}", "/proc/net/wireless").Output()
+	var qualities, err = wirelessQuality()
 	if err != nil {
 		return wifiSignOff + " ERR"
 	}
-	strength := strings.Trim(string(out), " ")
-	if strength != "" {
-		strengthInt, err := strconv.Atoi(strength)
-		if err != nil {
-			return wifiSignOff + " ERR"
-		}
-		var wifiSign = wifiSignFull
-		if strengthInt > 70 {
-			wifiSign = wifiSignFull
-		} else if strengthInt > 50 {
-			wifiSign = wifiSignHalf
-		} else if strengthInt > 20 {
-			wifiSign = wifiSignLow
-		} else {
-			wifiSign = wifiSignOff
-		}
-		if strengthInt >= 100 {
-			return wifiSign + "" + strength + "%"
-		} else if strengthInt >= 10 {
-			return wifiSign + " " + strength + "%"
-		} else {
-			return wifiSign + "  " + strength + "%"
-		}
-	} else {
+	notifyWhen("wifi", len(qualities) == 0, "normal", "Wi-Fi disconnected", "")
+	if len(qualities) == 0 {
 		return wifiSignOff + " 0%"
 	}
+	var best = 0.0
+	for _, quality := range qualities {
+		best = math.Max(best, quality)
+	}
+	var strength = int(math.Round(best / wifiMaxQuality * 100))
+	var wifiSign = wifiSignFull
+	if strength > 70 {
+		wifiSign = wifiSignFull
+	} else if strength > 50 {
+		wifiSign = wifiSignHalf
+	} else if strength > 20 {
+		wifiSign = wifiSignLow
+	} else {
+		wifiSign = wifiSignOff
+	}
+	return fmt.Sprintf("%s%3d%%", wifiSign, strength)
 }
 
-func updateCPUTemp() string {
-	var file, err = os.Open("/sys/class/thermal/thermal_zone1/temp")
+// wifiMaxQuality is the best link quality reported by most drivers
+const wifiMaxQuality = 70
+
+// wirelessQuality reads the link quality of each wireless interface from
+// /proc/net/wireless, which after two header lines has a line per interface:
+//
+//	wlp4s0: 0000   57.  -53.  -256        0      0      0      0     33        0
+func wirelessQuality() (map[string]float64, error) {
+	var content, err = ioutil.ReadFile(procPath("/proc/net/wireless"))
 	if err != nil {
-		return cpuTempSign + " ERR"
-	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	var tempStr = " ERR"
-	for scanner.Scan() {
-		tempStr = scanner.Text()
+		return nil, err
 	}
-	temp, err := strconv.Atoi(tempStr)
-	if err != nil {
-		return cpuTempSign + " ERR"
+	var qualities = map[string]float64{}
+	for _, line := range strings.Split(string(content), "\n") {
+		var fields = strings.Fields(line)
+		if len(fields) < 3 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		// updated values end in a dot
+		var quality, err = strconv.ParseFloat(strings.TrimSuffix(fields[2], "."), 64)
+		if err == nil {
+			qualities[strings.TrimSuffix(fields[0], ":")] = quality
+		}
 	}
-	temp = temp / 1000
-	return fmt.Sprintf("%s %d°C", cpuTempSign, temp)
+	return qualities, nil
 }
 
-func updateKeyboard() string {
-	var file, err = os.Open("/home/john/.config/xmodmap_switcher/state")
+func updateCPUTemp() string {
+	var temp, err = sys.cpuTemperature()
 	if err != nil {
-		return keyboardSign + " default"
-	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	var keyboard = "default"
-	for scanner.Scan() {
-		keyboard = scanner.Text()
+		return cpuTempSign + " ERR"
 	}
-	return keyboardSign + " " + keyboard
+	record("cputemp", float64(int(temp)))
+	return fmt.Sprintf("%s %d°C", cpuTempSign, int(temp))
 }
 
 func updateVpn() string {
-	out, err := exec.Command("nmcli", "conn", "show", "--active").Output()
+	out, err := timedCommand("nmcli", "conn", "show", "--active").Output()
 
 	if err != nil {
 		return vpnOff
@@ -387,49 +376,177 @@ func updateVpn() string {
 	return vpnOff
 }
 
-func getDistroSign() string {
-	var out, err = exec.Command("uname", "-a").Output()
-	if err != nil {
-		return ""
-	}
-	uname := string(out)
-	distroRx := regexp.MustCompile(`.*(arch|slack).*`)
-	distroMatch := distroRx.FindStringSubmatch(uname)
-	if len(distroMatch) == 1 {
-		return ""
-	} else if distroMatch[1] == "arch" {
-		return ""
-	} else if distroMatch[1] == "slack" {
-		return ""
-	} else {
-		return ""
-	}
+// modules make up the status bar in the order they are displayed. Each one is
+// updated every interval (plus a random jitter) on its own, an interval of 0
+// updates the module only once at startup.
+var modules = []*module{
+	{name: "dnd", interval: 5 * time.Second, update: updateDND},
+	{name: "notifications", interval: 5 * time.Second, update: updateNotifications},
+	{name: "clipboard", interval: 2 * time.Second, update: updateClipboard, off: true},
+	{name: "inhibit", interval: 5 * time.Second, update: updateInhibit},
+	{name: "inhibitors", interval: 30 * time.Second, update: updateInhibitors},
+	{name: "volume", interval: 5 * time.Second, update: updateVolume},
+	{name: "wifi", interval: 5 * time.Second, update: updateWifi},
+	{name: "vpn", interval: 5 * time.Second, update: updateVpn},
+	{name: "tor", interval: 30 * time.Second, update: updateTor, off: true},
+	{name: "net", interval: 5 * time.Second, update: updateNetUse},
+	{name: "nextcloud", interval: 10 * time.Second, update: updateNextcloud, off: true},
+	{name: "torrent", interval: 5 * time.Second, update: updateTorrent, off: true},
+	{name: "connectivity", interval: 10 * time.Second, update: updateConnectivity},
+	{name: "cpu", interval: 5 * time.Second, update: updateCPUUse},
+	{name: "cputemp", interval: 5 * time.Second, update: updateCPUTemp},
+	{name: "gputemp", interval: 5 * time.Second, update: updateGPUTemp, off: true},
+	{name: "pi", interval: 5 * time.Second, update: updatePi, off: true},
+	{name: "mem", interval: 5 * time.Second, update: updateMemUse},
+	{name: "disk", interval: time.Minute, update: updateDisks},
+	{name: "power", interval: 5 * time.Second, update: updatePower},
+	{name: "powertime", interval: time.Minute, update: updatePowerTime, off: true},
+	{name: "cups", interval: 10 * time.Second, update: updateCUPS},
+	{name: "peripherals", interval: time.Minute, update: updatePeripherals},
+	{name: "pomodoro", interval: time.Second, update: updatePomodoro},
+	{name: "timer", interval: time.Second, update: updateTimers},
+	{name: "alarm", interval: time.Second, update: updateAlarms},
+	{name: "break", interval: 5 * time.Second, update: updateBreak, off: true},
+	{name: "agenda", interval: time.Minute, update: updateAgenda, off: true},
+	{name: "weather", interval: 5 * time.Second, update: updateWeather, off: true},
+	{name: "moon", interval: time.Hour, update: updateMoon, off: true},
+	{name: "airquality", interval: time.Minute, update: updateAirQuality, off: true},
+	{name: "ticker", interval: time.Minute, update: updateTicker, off: true},
+	{name: "quotes", interval: 5 * time.Second, update: updateQuotes, off: true},
+	{name: "mail", interval: time.Minute, update: updateMail},
+	{name: "maildir", interval: 5 * time.Minute, update: updateMaildir, off: true},
+	{name: "github", interval: time.Minute, update: updateGitHub},
+	{name: "matrix", update: updateMatrix},
+	{name: "slack", interval: time.Minute, update: updateSlack, off: true},
+	{name: "telegram", update: updateTelegram},
+	{name: "tasks", interval: time.Minute, update: updateTasks, off: true},
+	{name: "todo", interval: time.Hour, update: updateTodo, off: true},
+	{name: "org", interval: time.Minute, update: updateOrg, off: true},
+	{name: "mqtt", update: updateMQTT},
+	{name: "homeassistant", interval: 5 * time.Second, update: updateHomeAssistant, off: true},
+	{name: "http", interval: 5 * time.Second, update: updateHTTP},
+	{name: "files", interval: time.Minute, update: updateFiles},
+	{name: "fifo", update: updateFIFO},
+	{name: "webhook", interval: 5 * time.Second, update: updateWebhook},
+	{name: "carousel", interval: time.Second}, // update is set in carousel.go
+	{name: "transit", interval: time.Second, update: updateTransit, off: true},
+	{name: "clock", interval: time.Second, update: updateClocks},
+	{name: "timesync", interval: 5 * time.Minute, update: updateTimeSync},
+	{name: "keyboard", update: updateKeyboard},
+	{name: "locks", update: updateLocks},
+	{name: "kbdlight", interval: 5 * time.Second, update: updateKbdLight},
+	{name: "kernel", interval: time.Minute, update: updateKernel},
+	{name: "restart", interval: 15 * time.Minute, update: updateRestart},
+	{name: "updates", interval: 2 * time.Hour, jitter: 15 * time.Minute, update: updatePackages},
+	{name: "fail2ban", interval: 10 * time.Minute, update: updateFail2ban, off: true},
+	{name: "ssh", interval: 30 * time.Second, update: updateSSH},
+	{name: "sessions", interval: 30 * time.Second, update: updateSessions},
+	{name: "failedunits", interval: time.Minute, update: updateFailedUnits},
+	{name: "journal", interval: 5 * time.Second, update: updateJournal},
+	{name: "containers", interval: 30 * time.Second, update: updateContainers},
+	{name: "vms", interval: 30 * time.Second, update: updateVMs},
+	{name: "entropy", interval: time.Minute, update: updateEntropy, off: true},
+	{name: "distro", update: updateDistro},
 }
 
-// main updates the dwm statusbar every second
+// main updates the dwm statusbar (or the configured output) whenever a module's text changes
 func main() {
-	distroSign := getDistroSign()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ctl":
+			os.Exit(ctl(os.Args[2:]))
+		case "timer":
+			os.Exit(ctl(os.Args[1:]))
+		case "init":
+			os.Exit(initConfig())
+		case "import":
+			os.Exit(importConfig(os.Args[2:]))
+		case "config":
+			os.Exit(dumpConfig(os.Args[2:]))
+		case "click":
+			// statuscmd passes the mouse button in the environment
+			if len(os.Args) == 3 && os.Getenv("BUTTON") != "" {
+				os.Exit(ctl(append(os.Args[1:], os.Getenv("BUTTON"))))
+			}
+			os.Exit(ctl(os.Args[1:]))
+		}
+	}
+	var replace = len(os.Args) > 1 && os.Args[1] == "--replace"
+	// the demo leaves a running instance alone
+	var demoMode = len(os.Args) > 1 && os.Args[1] == "--demo"
+	if err := loadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		os.Exit(1)
+	}
+	if err := validateConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		os.Exit(1)
+	}
+	enableModules()
+	var draw = outputs[cfg.Output]
+	sys = collectors[cfg.Collector]
+	if demoMode {
+		// the running instance owns the bar, so the demo prints to stdout
+		// unless told otherwise, e.g. gods --demo xsetroot
+		draw = outputs["stdout"]
+		if len(os.Args) > 2 {
+			draw = outputs[os.Args[2]]
+		}
+		if draw == nil {
+			fmt.Fprintln(os.Stderr, "gods: unknown output", os.Args[2])
+			os.Exit(1)
+		}
+		startDemo()
+	} else if err := lockInstance(replace); err == errRunning {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		os.Exit(1)
+	}
+
+	for _, m := range modules {
+		m.wake = make(chan struct{}, 1)
+		for _, name := range cfg.Hide {
+			m.hidden = m.hidden || m.name == name
+		}
+	}
+	go followJournal()
+	// the control socket, the FIFO, the webhook port and the accounts belong
+	// to the running instance, not to the demo
+	if !demoMode {
+		go serveControl()
+		watchMail()
+		go watchMatrix()
+		go watchTelegram()
+		go watchMQTT()
+		go watchFIFO()
+		go serveWebhook()
+	}
+	go watchMaildirs()
+	go watchTodo()
+	go watchFiles()
+	go watchKeyboard()
+	go watchKbdLight()
+	go watchModeSignal()
+	if !demoMode {
+		go logHistory()
+		go sendStatsD()
+		go pushInflux()
+	}
+	for _, m := range modules {
+		if !m.off {
+			go m.run()
+		}
+	}
 	for {
-		var status = []string{
-			"",
-			updateVolume(),
-			updateWifi(),
-			updateVpn(),
-			updateNetUse(),
-			updateCPUUse(),
-			updateCPUTemp(),
-			updateMemUse(),
-			updatePower(),
-			//updatePowerTime(),
-			time.Now().Local().Format(dateSeparator + " Mon Jan 02 15:04"),
-			updateKeyboard(),
-			distroSign,
+		select {
+		case <-redraws:
+			draw(render())
+		case <-quitting:
+			// don't leave the logind inhibitor behind
+			inhibitCommand([]string{"off"})
+			return
 		}
-		exec.Command("xsetroot", "-name", strings.Join(status, fieldSeparator)).Run()
-
-		// sleep until beginning of next second
-		// var now = time.Now()
-		// time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
-		time.Sleep(5 * time.Second)
 	}
 }
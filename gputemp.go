@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	gpuTempSign = ""
+)
+
+// updateGPUTemp shows the temperature of the graphics card, read from the
+// hwmon of the DRM driver or from nvidia-smi for the proprietary driver. It
+// hides itself if there is no such card.
+func updateGPUTemp() string {
+	var temp, err = gpuTemp()
+	if err != nil {
+		return ""
+	}
+	record("gputemp", float64(temp))
+	return fmt.Sprintf("%s %d°C", gpuTempSign, temp)
+}
+
+// gpuTemp returns the temperature of the first graphics card in °C
+func gpuTemp() (int, error) {
+	var inputs, _ = filepath.Glob(sysPath("/sys/class/drm/card*/device/hwmon/hwmon*/temp1_input"))
+	for _, input := range inputs {
+		var content, err = ioutil.ReadFile(input)
+		if err != nil {
+			continue
+		}
+		if millis, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil {
+			return millis / 1000, nil
+		}
+	}
+	var out, err = timedCommand("nvidia-smi", "--query-gpu=temperature.gpu", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// historyConfig enables logging the metrics to a CSV file
+type historyConfig struct {
+	// Interval is how often the metrics are written, 0 disables logging
+	Interval duration `json:"interval"`
+	// File defaults to metrics.csv in gods' state directory
+	File string `json:"file,omitempty"`
+	// Metrics limits the logged metrics, all are logged by default
+	Metrics []string `json:"metrics,omitempty"`
+	// MaxSize rotates the file once it grows past this many bytes, Keep is
+	// the number of rotated files kept as file.1, file.2 and so on
+	MaxSize int64 `json:"maxsize"`
+	Keep    int   `json:"keep"`
+}
+
+// historyFile returns the location of the metrics log
+func historyFile() string {
+	if cfg.History.File != "" {
+		return expandPath(cfg.History.File)
+	}
+	return statePath("metrics.csv")
+}
+
+// logHistory appends the current metrics to the log every interval, one
+// "time,metric,value" line per metric
+func logHistory() {
+	if cfg.History.Interval.Duration <= 0 {
+		return
+	}
+	for range time.Tick(cfg.History.Interval.Duration) {
+		if err := writeHistory(time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, "gods: history:", err)
+		}
+	}
+}
+
+// writeHistory appends a sample of the metrics to the log
+func writeHistory(now time.Time) error {
+	var path = historyFile()
+	rotateHistory(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintln(file, "time,metric,value")
+	}
+
+	var names, values = snapshot(cfg.History.Metrics)
+	var lines []byte
+	for _, name := range names {
+		lines = append(lines, now.Format(time.RFC3339)+","+name+","+
+			strconv.FormatFloat(values[name], 'f', -1, 64)+"\n"...)
+	}
+	_, err = file.Write(lines)
+	return err
+}
+
+// rotateHistory moves the log aside once it grew too large, dropping the
+// oldest one
+func rotateHistory(path string) {
+	var info, err = os.Stat(path)
+	if err != nil || cfg.History.MaxSize <= 0 || info.Size() < cfg.History.MaxSize {
+		return
+	}
+	for i := cfg.History.Keep; i > 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i-1), fmt.Sprintf("%s.%d", path, i))
+	}
+	if cfg.History.Keep > 0 {
+		os.Rename(path, path+".1")
+	} else {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	homeAssistantSign = ""
+)
+
+// homeAssistantConfig selects the Home Assistant entities shown in the bar
+type homeAssistantConfig struct {
+	// URL of the Home Assistant instance, e.g. http://homeassistant.local:8123
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty" secret:"true"`
+	// TokenCommand prints the long-lived access token instead
+	TokenCommand string                `json:"tokencommand,omitempty"`
+	Entities     []homeAssistantEntity `json:"entities,omitempty"`
+	Refresh      duration              `json:"refresh"`
+}
+
+// homeAssistantEntity is rendered with a text/template, which gets the
+// entity's .State, .Name and .Attributes. An empty result hides the entity.
+type homeAssistantEntity struct {
+	Entity   string `json:"entity"`
+	Template string `json:"template,omitempty"`
+	// Service is called by `gods ctl homeassistant call <entity>`, e.g.
+	// "light.toggle"
+	Service string `json:"service,omitempty"`
+}
+
+// entityState is what an entity's template is executed on
+type entityState struct {
+	State      string
+	Name       string
+	Attributes map[string]interface{}
+}
+
+var homeAssistant struct {
+	sync.Mutex
+	states    map[string]entityState
+	templates map[string]*template.Template
+	poll      poller
+}
+
+// updateHomeAssistant renders the states of the configured entities
+func updateHomeAssistant() string {
+	if cfg.HomeAssistant.URL == "" {
+		return ""
+	}
+	homeAssistant.Lock()
+	defer homeAssistant.Unlock()
+	if homeAssistant.poll.due() {
+		homeAssistant.poll.refresh = cfg.HomeAssistant.Refresh.Duration
+		var states, err = fetchHomeAssistant()
+		homeAssistant.poll.done(err)
+		if err == nil {
+			homeAssistant.states = states
+		}
+	}
+	if homeAssistant.states == nil {
+		return homeAssistantSign + " ERR"
+	}
+
+	var fields []string
+	for _, entity := range cfg.HomeAssistant.Entities {
+		var state, ok = homeAssistant.states[entity.Entity]
+		if !ok {
+			fields = append(fields, entity.Entity+" ERR")
+			continue
+		}
+		var text, err = renderEntity(entity, state)
+		if err != nil {
+			fields = append(fields, entity.Entity+" ERR")
+		} else if text != "" {
+			fields = append(fields, text)
+		}
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// renderEntity executes the entity's template, which is parsed once
+func renderEntity(entity homeAssistantEntity, state entityState) (string, error) {
+	if homeAssistant.templates == nil {
+		homeAssistant.templates = map[string]*template.Template{}
+	}
+	var tmpl, ok = homeAssistant.templates[entity.Entity]
+	if !ok {
+		var text = entity.Template
+		if text == "" {
+			text = "{{.Name}} {{.State}}"
+		}
+		var err error
+		if tmpl, err = template.New(entity.Entity).Parse(text); err != nil {
+			return "", err
+		}
+		homeAssistant.templates[entity.Entity] = tmpl
+	}
+	var out bytes.Buffer
+	var err = tmpl.Execute(&out, state)
+	return strings.TrimSpace(out.String()), err
+}
+
+// fetchHomeAssistant reads the states of all entities at once
+func fetchHomeAssistant() (map[string]entityState, error) {
+	var resp, err = homeAssistantRequest("GET", "/api/states", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var entities []struct {
+		EntityID   string `json:"entity_id"`
+		State      string
+		Attributes map[string]interface{}
+	}
+	if err = decodeJSON(resp, &entities); err != nil {
+		return nil, err
+	}
+	var states = map[string]entityState{}
+	for _, e := range entities {
+		var name, _ = e.Attributes["friendly_name"].(string)
+		states[e.EntityID] = entityState{State: e.State, Name: name, Attributes: e.Attributes}
+	}
+	return states, nil
+}
+
+// homeAssistantCommand calls the service configured for an entity
+func homeAssistantCommand(args []string) (string, error) {
+	if len(args) != 2 || args[0] != "call" {
+		return "", fmt.Errorf("usage: homeassistant call <entity>")
+	}
+	for _, entity := range cfg.HomeAssistant.Entities {
+		if entity.Entity != args[1] || entity.Service == "" {
+			continue
+		}
+		var body, _ = json.Marshal(map[string]string{"entity_id": entity.Entity})
+		var resp, err = homeAssistantRequest("POST", "/api/services/"+strings.Replace(entity.Service, ".", "/", 1), body)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("homeassistant: %s", resp.Status)
+		}
+		// show the new state right away
+		homeAssistant.Lock()
+		homeAssistant.poll.next = time.Time{}
+		homeAssistant.Unlock()
+		refresh("homeassistant")
+		return "", nil
+	}
+	return "", fmt.Errorf("no service configured for %s", args[1])
+}
+
+// homeAssistantRequest sends an authorized request to the REST API
+func homeAssistantRequest(method, path string, body []byte) (*http.Response, error) {
+	var token = cfg.HomeAssistant.Token
+	if cfg.HomeAssistant.TokenCommand != "" {
+		var err error
+		if token, err = secret(cfg.HomeAssistant.TokenCommand); err != nil {
+			return nil, err
+		}
+	}
+	var req, err = http.NewRequest(method, strings.TrimSuffix(cfg.HomeAssistant.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	return weatherClient.Do(req)
+}
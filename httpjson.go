@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// httpField fetches a JSON document and shows a value from it
+type httpField struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty" secret:"true"`
+	// Path selects the value with dot separated keys and array indexes like
+	// "data.items.0.price", "#" counts the elements of an array or object
+	Path string `json:"path,omitempty"`
+	// Template renders the value given as {{.}}, e.g. "BTC {{.}}". An empty
+	// result hides the field.
+	Template string   `json:"template,omitempty"`
+	Refresh  duration `json:"refresh"`
+}
+
+// httpState is the last rendered text of a field
+type httpState struct {
+	text    string
+	fetched bool
+	poll    poller
+	tmpl    *template.Template
+}
+
+var httpStates = map[int]*httpState{}
+
+// updateHTTP shows the values of all configured HTTP fields, each fetched at
+// its own refresh interval
+func updateHTTP() string {
+	var fields []string
+	for i, field := range cfg.HTTP {
+		var state, ok = httpStates[i]
+		if !ok {
+			state = &httpState{poll: poller{refresh: field.Refresh.Duration}}
+			if state.poll.refresh == 0 {
+				state.poll.refresh = 5 * time.Minute
+			}
+			httpStates[i] = state
+		}
+		if state.poll.due() {
+			var text, err = fetchHTTPField(field, state)
+			state.poll.done(err)
+			if err == nil {
+				state.text, state.fetched = text, true
+			}
+		}
+
+		if !state.fetched {
+			fields = append(fields, "http ERR")
+		} else if state.text != "" {
+			fields = append(fields, state.text)
+		}
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// fetchHTTPField fetches the document and renders the selected value
+func fetchHTTPField(field httpField, state *httpState) (string, error) {
+	if state.tmpl == nil {
+		var text = field.Template
+		if text == "" {
+			text = "{{.}}"
+		}
+		var tmpl, err = template.New(field.URL).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		state.tmpl = tmpl
+	}
+
+	var req, err = http.NewRequest("GET", field.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range field.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := weatherClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	// numbers are shown as written instead of in float notation
+	var decoder = json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	var document interface{}
+	if err = decoder.Decode(&document); err != nil {
+		return "", err
+	}
+	value, err := jsonPath(document, field.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	err = state.tmpl.Execute(&out, value)
+	return strings.TrimSpace(out.String()), err
+}
+
+// jsonPath selects a value from a decoded JSON document
+func jsonPath(value interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return value, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var field, ok = v[key]
+			if key == "#" {
+				field, ok = len(v), true
+			}
+			if !ok {
+				return nil, fmt.Errorf("%s: no key %q", path, key)
+			}
+			value = field
+		case []interface{}:
+			if key == "#" {
+				value = len(v)
+				continue
+			}
+			var i, err = strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s: no index %q", path, key)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("%s: %q is not an object or array", path, key)
+		}
+	}
+	return value, nil
+}
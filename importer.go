@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// imported is what could be taken over from another status bar's config
+type imported struct {
+	modules map[string]bool // the gods modules with a counterpart in use
+	clocks  []clockConfig
+	mounts  []string
+	skipped []string // entries gods has nothing for
+}
+
+// importers read the config of another status bar, as in
+// `gods import --from i3status ~/.config/i3status/config`
+var importers = map[string]func(content string) (*imported, error){
+	"slstatus": importSlstatus,
+	"i3status": importI3status,
+}
+
+// counterparts are the gods modules the imported configs can refer to. Those
+// not referred to are hidden, all others are left as they are.
+var counterparts = []string{"clock", "cpu", "cputemp", "disk", "keyboard", "kernel",
+	"mem", "net", "power", "volume", "vpn", "wifi"}
+
+// importConfig translates the config file of another status bar and prints
+// the result, so it can be looked at before it goes to configPath. It
+// returns the exit code.
+func importConfig(args []string) int {
+	if len(args) != 3 || args[0] != "--from" || importers[args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: gods import --from slstatus|i3status <file>")
+		return 1
+	}
+	var content, err = ioutil.ReadFile(expandPath(args[2]))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		return 1
+	}
+	result, err := importers[args[1]](string(content))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gods: %s: %v\n", args[2], err)
+		return 1
+	}
+
+	var out = map[string]interface{}{}
+	var hide []string
+	for _, name := range counterparts {
+		if enabled(name) && !result.modules[name] {
+			hide = append(hide, name)
+		}
+	}
+	if len(hide) > 0 {
+		out["hide"] = hide
+	}
+	if len(result.clocks) > 0 {
+		out["clocks"] = result.clocks
+	}
+	if len(result.mounts) > 0 {
+		var disks []diskConfig
+		for _, mount := range result.mounts {
+			disks = append(disks, diskConfig{Mount: mount, Thresholds: []int{90, 98}})
+		}
+		out["disks"] = disks
+	}
+	for _, entry := range result.skipped {
+		fmt.Fprintln(os.Stderr, "gods: not imported:", entry)
+	}
+	var data, _ = json.MarshalIndent(out, "", "\t")
+	fmt.Println(string(data))
+	return 0
+}
+
+// slstatusModules maps the functions of slstatus to gods modules
+var slstatusModules = map[string]string{
+	"battery_perc": "power", "battery_state": "power", "battery_remaining": "power",
+	"cpu_perc": "cpu", "cpu_freq": "cpu", "load_avg": "cpu",
+	"ram_free": "mem", "ram_perc": "mem", "ram_total": "mem", "ram_used": "mem",
+	"swap_free": "mem", "swap_perc": "mem", "swap_total": "mem", "swap_used": "mem",
+	"temp":      "cputemp",
+	"disk_free": "disk", "disk_perc": "disk", "disk_total": "disk", "disk_used": "disk",
+	"wifi_perc": "wifi", "wifi_essid": "wifi",
+	"netspeed_rx": "net", "netspeed_tx": "net", "ipv4": "net", "ipv6": "net",
+	"datetime": "clock",
+	"vol_perc": "volume",
+	"keymap":   "keyboard", "keyboard_indicators": "keyboard",
+	"kernel_release": "kernel",
+}
+
+// slstatusArg matches an entry of the args array in slstatus' config.h, like
+// `{ datetime, "%s", "%F %T" },`
+var slstatusArg = regexp.MustCompile(`\{\s*(\w+)\s*,\s*"(?:[^"\\]|\\.)*"\s*,\s*("(?:[^"\\]|\\.)*"|NULL)\s*\}`)
+
+// importSlstatus reads the args array of slstatus' config.h
+func importSlstatus(content string) (*imported, error) {
+	var start = strings.Index(content, "args[]")
+	if start < 0 {
+		return nil, fmt.Errorf("no args array found")
+	}
+	content = content[start:]
+	if end := strings.Index(content, "};"); end >= 0 {
+		content = content[:end]
+	}
+
+	var result = &imported{modules: map[string]bool{}}
+	for _, m := range slstatusArg.FindAllStringSubmatch(content, -1) {
+		var name, ok = slstatusModules[m[1]]
+		if !ok {
+			result.skipped = append(result.skipped, m[1])
+			continue
+		}
+		result.modules[name] = true
+		var arg = unquoteC(m[2])
+		switch {
+		case m[1] == "datetime":
+			var format, err = strftimeLayout(arg)
+			if err != nil {
+				result.skipped = append(result.skipped, fmt.Sprintf("datetime %q: %v", arg, err))
+				continue
+			}
+			result.clocks = append(result.clocks, clockConfig{Format: format})
+		case strings.HasPrefix(m[1], "disk_"):
+			result.addMount(arg)
+		}
+	}
+	return result, nil
+}
+
+// i3statusModules maps the modules of i3status to gods modules
+var i3statusModules = map[string]string{
+	"battery":         "power",
+	"cpu_usage":       "cpu",
+	"load":            "cpu",
+	"cpu_temperature": "cputemp",
+	"memory":          "mem",
+	"disk":            "disk",
+	"wireless":        "wifi",
+	"ethernet":        "net",
+	"ipv6":            "net",
+	"tztime":          "clock",
+	"time":            "clock",
+	"volume":          "volume",
+}
+
+// importI3status reads the `order +=` lines of an i3status config and the
+// settings of the modules named there
+func importI3status(content string) (*imported, error) {
+	var order []string
+	var blocks = map[string]map[string]string{}
+	var block map[string]string
+	for scanner := bufio.NewScanner(strings.NewReader(content)); scanner.Scan(); {
+		var line = strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "order"):
+			// order += "disk /"
+			var i = strings.Index(line, "+=")
+			if i >= 0 {
+				order = append(order, i3statusName(line[i+2:]))
+			}
+		case strings.HasSuffix(line, "{"):
+			block = map[string]string{}
+			blocks[i3statusName(strings.TrimSuffix(line, "{"))] = block
+		case line == "}":
+			block = nil
+		case block != nil && strings.Contains(line, "="):
+			var kv = strings.SplitN(line, "=", 2)
+			block[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		}
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no order += lines found")
+	}
+
+	var result = &imported{modules: map[string]bool{}}
+	for _, entry := range order {
+		var fields = strings.SplitN(entry, " ", 2)
+		var kind, instance = fields[0], ""
+		if len(fields) == 2 {
+			instance = fields[1]
+		}
+		var name, ok = i3statusModules[kind]
+		if kind == "path_exists" || kind == "run_watch" {
+			// usually named VPN, as in the default config
+			name, ok = "vpn", strings.EqualFold(instance, "vpn")
+		}
+		if !ok {
+			result.skipped = append(result.skipped, entry)
+			continue
+		}
+		result.modules[name] = true
+		switch kind {
+		case "tztime", "time":
+			var settings = blocks[entry]
+			var strftime = settings["format"]
+			if strftime == "" {
+				strftime = "%Y-%m-%d %H:%M:%S"
+			}
+			var format, err = strftimeLayout(strftime)
+			if err != nil {
+				result.skipped = append(result.skipped, fmt.Sprintf("%s %q: %v", entry, strftime, err))
+				continue
+			}
+			result.clocks = append(result.clocks, clockConfig{Format: format, Timezone: settings["timezone"]})
+		case "disk":
+			result.addMount(instance)
+		}
+	}
+	return result, nil
+}
+
+// i3statusName normalizes a module like ` "disk /" ` or `disk "/" `
+func i3statusName(s string) string {
+	return strings.Join(strings.Fields(strings.Replace(s, `"`, "", -1)), " ")
+}
+
+// addMount adds a mount point once
+func (result *imported) addMount(mount string) {
+	if mount == "" {
+		return
+	}
+	for _, known := range result.mounts {
+		if known == mount {
+			return
+		}
+	}
+	result.mounts = append(result.mounts, mount)
+}
+
+// unquoteC returns the text of a C string literal, NULL is empty. The
+// escapes C and JSON have in common are undone.
+func unquoteC(literal string) string {
+	var s string
+	if literal == "NULL" {
+		return ""
+	} else if err := json.Unmarshal([]byte(literal), &s); err != nil {
+		return strings.Trim(literal, `"`)
+	}
+	return s
+}
+
+// strftimeLayouts are the Go layouts of the strftime conversions
+var strftimeLayouts = map[byte]string{
+	'a': "Mon", 'A': "Monday", 'b': "Jan", 'h': "Jan", 'B': "January",
+	'd': "02", 'e': "_2", 'm': "01", 'y': "06", 'Y': "2006", 'j': "002",
+	'H': "15", 'I': "03", 'M': "04", 'S': "05", 'p': "PM",
+	'Z': "MST", 'z': "-0700",
+	'F': "2006-01-02", 'T': "15:04:05", 'R': "15:04", 'D': "01/02/06",
+	'n': "\n", 't': "\t", '%': "%",
+}
+
+// strftimeLayout translates a strftime(3) format to a Go reference time layout
+func strftimeLayout(format string) (string, error) {
+	var layout strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			layout.WriteByte(format[i])
+			continue
+		}
+		if i++; i == len(format) {
+			return "", fmt.Errorf("trailing %%")
+		}
+		var part, ok = strftimeLayouts[format[i]]
+		if !ok {
+			return "", fmt.Errorf("%%%c has no Go equivalent", format[i])
+		}
+		layout.WriteString(part)
+	}
+	return layout.String(), nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStrftimeLayout(t *testing.T) {
+	var tests = []struct {
+		format, want string
+		fail         bool
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2006-01-02 15:04:05", false},
+		{"%a %e %b %R", "Mon _2 Jan 15:04", false},
+		{"%F %T %Z", "2006-01-02 15:04:05 MST", false},
+		{"%I:%M %p, 100%%", "03:04 PM, 100%", false},
+		{"W%V", "", true},
+		{"%H:%M %", "", true},
+	}
+	for _, test := range tests {
+		var got, err = strftimeLayout(test.format)
+		if got != test.want || (err != nil) != test.fail {
+			t.Errorf("strftimeLayout(%q) = %q, %v, want %q", test.format, got, err, test.want)
+		}
+	}
+}
+
+const i3statusConfig = `# i3status configuration file.
+general {
+        colors = true
+        interval = 5
+}
+
+order += "ipv6"
+order += "wireless _first_"
+order += "ethernet _first_"
+order += "battery all"
+order += "disk /"
+order += "disk /home"
+order += "run_watch VPN"
+order += "path_exists BACKUP"
+order += "load"
+order += "tztime local"
+order += "tztime berlin"
+
+tztime local {
+        format = "%Y-%m-%d %H:%M:%S"
+}
+
+tztime berlin {
+        format = "%H:%M %Z"
+        timezone = "Europe/Berlin"
+}
+
+disk "/" {
+        format = "%avail"
+}
+`
+
+func TestImportI3status(t *testing.T) {
+	var got, err = importI3status(i3statusConfig)
+	if err != nil {
+		t.Fatalf("importI3status() failed: %v", err)
+	}
+	var want = &imported{
+		modules: map[string]bool{"net": true, "wifi": true, "power": true,
+			"disk": true, "vpn": true, "cpu": true, "clock": true},
+		clocks: []clockConfig{
+			{Format: "2006-01-02 15:04:05"},
+			{Format: "15:04 MST", Timezone: "Europe/Berlin"},
+		},
+		mounts:  []string{"/", "/home"},
+		skipped: []string{"path_exists BACKUP"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("importI3status() = %+v, want %+v", got, want)
+	}
+
+	if _, err = importI3status("general {\n}\n"); err == nil {
+		t.Errorf("importI3status() without order lines succeeded")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxConfig enables pushing the metrics to InfluxDB or anything else that
+// accepts its line protocol, e.g. VictoriaMetrics
+type influxConfig struct {
+	// URL of the write endpoint including its parameters, e.g.
+	// "http://localhost:8086/api/v2/write?org=home&bucket=gods" or
+	// "http://localhost:8428/write". Timestamps are sent in nanoseconds.
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty" secret:"true"`
+	// TokenCommand prints the API token instead
+	TokenCommand string `json:"tokencommand,omitempty"`
+	// Measurement defaults to "gods", host=<hostname> is added to Tags
+	Measurement string            `json:"measurement,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	// Interval is how often a sample is taken, Batch how many samples are
+	// sent at once. Samples are kept while the endpoint is unreachable, up to
+	// Buffer of them.
+	Interval duration `json:"interval"`
+	Batch    int      `json:"batch"`
+	Buffer   int      `json:"buffer"`
+	// Metrics limits the metrics sent, all are sent by default
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+// influxEscape escapes measurements, tags and field keys in line protocol
+var influxEscape = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// pushInflux samples the metrics every interval and writes them in batches,
+// failed writes are retried with backoff
+func pushInflux() {
+	if cfg.Influx.URL == "" {
+		return
+	}
+	var series = influxSeries()
+	var lines [][]byte
+	var poll poller
+	for now := range time.Tick(cfg.Influx.Interval.Duration) {
+		if line := influxLine(series, now); line != nil {
+			lines = append(lines, line)
+		}
+		if len(lines) > cfg.Influx.Buffer {
+			lines = lines[len(lines)-cfg.Influx.Buffer:]
+		}
+		if len(lines) < cfg.Influx.Batch || !poll.due() {
+			continue
+		}
+		var err = writeInflux(bytes.Join(lines, nil))
+		poll.done(err)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gods: influx:", err)
+		} else {
+			lines = nil
+		}
+	}
+}
+
+// influxSeries returns the measurement and tags all lines start with
+func influxSeries() string {
+	var measurement = cfg.Influx.Measurement
+	if measurement == "" {
+		measurement = "gods"
+	}
+	var tags = map[string]string{}
+	if hostname, err := os.Hostname(); err == nil {
+		tags["host"] = hostname
+	}
+	for key, value := range cfg.Influx.Tags {
+		tags[key] = value
+	}
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // as recommended for performance
+	var series = strings.Replace(influxEscape.Replace(measurement), `\=`, "=", -1)
+	for _, key := range keys {
+		series += "," + influxEscape.Replace(key) + "=" + influxEscape.Replace(tags[key])
+	}
+	return series
+}
+
+// influxLine formats the current metrics as a single line with one field per
+// metric, nil if there are none yet
+func influxLine(series string, now time.Time) []byte {
+	var names, values = snapshot(cfg.Influx.Metrics)
+	if len(names) == 0 {
+		return nil
+	}
+	var fields []string
+	for _, name := range names {
+		fields = append(fields, influxEscape.Replace(name)+"="+strconv.FormatFloat(values[name], 'f', -1, 64))
+	}
+	return []byte(series + " " + strings.Join(fields, ",") + " " + strconv.FormatInt(now.UnixNano(), 10) + "\n")
+}
+
+// influxClient posts the batches, which are kept for the next try when it
+// gives up
+var influxClient = &http.Client{Timeout: 30 * time.Second}
+
+// influxToken caches the output of TokenCommand until the endpoint rejects
+// it. Only pushInflux uses it.
+var influxToken string
+
+// writeInflux posts a batch of lines to the write endpoint
+func writeInflux(body []byte) error {
+	var token = cfg.Influx.Token
+	if cfg.Influx.TokenCommand != "" {
+		if influxToken == "" {
+			var err error
+			if influxToken, err = secret(cfg.Influx.TokenCommand); err != nil {
+				return err
+			}
+		}
+		token = influxToken
+	}
+	var req, err = http.NewRequest("POST", cfg.Influx.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := influxClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// the token may have been rotated, ask TokenCommand again next time
+		influxToken = ""
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	inhibitSign = ""
+)
+
+var inhibitor struct {
+	sync.Mutex
+	cmd *exec.Cmd // holds the logind inhibitor while running
+}
+
+// updateInhibit shows an icon while screen blanking is inhibited, by gods
+// itself, by another program through logind or because the X screensaver
+// and DPMS are off. It hides itself otherwise.
+func updateInhibit() string {
+	inhibitor.Lock()
+	var own = inhibitor.cmd != nil
+	inhibitor.Unlock()
+	switch {
+	case own:
+		return colorWarning + inhibitSign + colorNormal
+	case idleInhibited() || screensaverOff():
+		return inhibitSign
+	}
+	return ""
+}
+
+// idleInhibited reports whether a logind inhibitor blocks idle
+func idleInhibited() bool {
+	var inhibitors, err = listInhibitors()
+	if err != nil {
+		return false
+	}
+	for _, inhibitor := range inhibitors {
+		if inhibitor.mode != "block" {
+			continue
+		}
+		for _, what := range inhibitor.what {
+			if what == "idle" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// screensaverOff reports whether X never blanks the screen
+func screensaverOff() bool {
+	var out, err = timedCommand("xset", "q").Output()
+	if err != nil {
+		return false
+	}
+	var timeout, dpms = "", true
+	for _, line := range strings.Split(string(out), "\n") {
+		var fields = strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "timeout:":
+			timeout = fields[1]
+		case strings.Contains(line, "DPMS is Disabled"):
+			dpms = false
+		}
+	}
+	return timeout == "0" && !dpms
+}
+
+// inhibitCommand takes or releases the inhibitor owned by gods. It holds a
+// logind idle lock and turns off the X screensaver and DPMS.
+func inhibitCommand(args []string) (string, error) {
+	inhibitor.Lock()
+	defer refresh("inhibit")
+	defer inhibitor.Unlock()
+	var on = inhibitor.cmd == nil
+	switch strings.Join(args, " ") {
+	case "toggle":
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		return "", fmt.Errorf("usage: inhibit toggle|on|off")
+	}
+
+	if on && inhibitor.cmd == nil {
+		var cmd = exec.Command("systemd-inhibit", "--what=idle", "--who=gods",
+			"--why=Inhibited from the status bar", "sleep", "infinity")
+		// its own process group, so sleep goes along with it
+		ownProcessGroup(cmd)
+		if err := cmd.Start(); err != nil {
+			return "", err
+		}
+		inhibitor.cmd = cmd
+		timedCommand("xset", "s", "off", "-dpms").Run()
+	} else if !on && inhibitor.cmd != nil {
+		killProcessGroup(inhibitor.cmd)
+		inhibitor.cmd.Wait()
+		inhibitor.cmd = nil
+		timedCommand("xset", "s", "on", "+dpms").Run()
+	}
+	return "", nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	inhibitorsSign = ""
+)
+
+// lockInhibitor is a lock a program holds through logind to block or delay
+// e.g. suspend
+type lockInhibitor struct {
+	what []string // the operations inhibited, like sleep and idle
+	who  string
+	mode string // "block" or "delay"
+}
+
+// updateInhibitors shows which programs block suspend or idle, naming them
+// in the expanded layout. Blocking suspend or the lid switch, which keeps a
+// closed laptop awake, is shown in the warning color. It hides itself if
+// nothing blocks, gods' own inhibitor is left to the inhibit module.
+func updateInhibitors() string {
+	var inhibitors, err = listInhibitors()
+	if err != nil {
+		return inhibitorsSign + " ERR"
+	}
+	var who []string
+	var sleep = false
+	for _, inhibitor := range inhibitors {
+		if inhibitor.mode != "block" || inhibitor.who == "gods" {
+			continue
+		}
+		var blocks = false
+		for _, what := range inhibitor.what {
+			switch what {
+			case "sleep", "handle-lid-switch", "handle-suspend-key":
+				sleep = true
+				blocks = true
+			case "idle":
+				blocks = true
+			}
+		}
+		if blocks {
+			who = append(who, inhibitor.who)
+		}
+	}
+
+	var text string
+	switch {
+	case len(who) == 0:
+		return ""
+	case expanded():
+		text = inhibitorsSign + " " + strings.Join(who, " ")
+	default:
+		text = fmt.Sprintf("%s %d", inhibitorsSign, len(who))
+	}
+	if sleep {
+		return colorWarning + text + colorNormal
+	}
+	return text
+}
+
+// listInhibitors calls ListInhibitors of logind, which returns
+// (what, who, why, mode, uid, pid) for each inhibitor
+func listInhibitors() ([]lockInhibitor, error) {
+	var out, err = output(nil, "busctl", "call", "--json=short", "org.freedesktop.login1",
+		"/org/freedesktop/login1", "org.freedesktop.login1.Manager", "ListInhibitors")
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Data [][][]interface{} `json:"data"`
+	}
+	if err = json.Unmarshal(out, &reply); err != nil {
+		return nil, err
+	}
+	if len(reply.Data) != 1 {
+		return nil, fmt.Errorf("ListInhibitors: unexpected reply")
+	}
+
+	var inhibitors []lockInhibitor
+	for _, row := range reply.Data[0] {
+		var fields [4]string
+		for i := range fields {
+			if i < len(row) {
+				fields[i], _ = row[i].(string)
+			}
+		}
+		inhibitors = append(inhibitors, lockInhibitor{
+			what: strings.Split(fields[0], ":"),
+			who:  fields[1],
+			mode: fields[3],
+		})
+	}
+	return inhibitors, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// replaceTimeout is how long gods --replace waits for the old instance to quit
+const replaceTimeout = 5 * time.Second
+
+// errRunning is returned by lockInstance while another instance holds the lock
+var errRunning = errors.New("already running, start with --replace to take over")
+
+// lockFile stays open for as long as gods runs, the lock goes with it
+var lockFile *os.File
+
+// quitting is closed once gods should shut down
+var quitting = make(chan struct{})
+
+// quitOnce closes quitting, even if quit is sent several times
+var quitOnce sync.Once
+
+// lockPath returns the location of the lock file, next to the control socket
+func lockPath() string {
+	return strings.TrimSuffix(socketPath(), ".sock") + ".lock"
+}
+
+// lockInstance makes sure only one gods runs per user. Another instance is
+// asked to quit if replace is set, otherwise errRunning is returned. The lock
+// is released by the kernel when gods exits, even if it crashes.
+func lockInstance(replace bool) error {
+	var file, err = os.OpenFile(lockPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	err = flock(file)
+	if err == syscall.EWOULDBLOCK && replace {
+		if err = askQuit(); err != nil {
+			file.Close()
+			return fmt.Errorf("replace: %v", err)
+		}
+		for deadline := time.Now().Add(replaceTimeout); err == syscall.EWOULDBLOCK && time.Now().Before(deadline); {
+			time.Sleep(100 * time.Millisecond)
+			err = flock(file)
+		}
+	}
+	if err == syscall.EWOULDBLOCK {
+		err = errRunning
+		if replace {
+			err = fmt.Errorf("replace: old instance still running after %v", replaceTimeout)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	file.Truncate(0)
+	fmt.Fprintln(file, os.Getpid())
+	lockFile = file
+	return nil
+}
+
+// askQuit sends the quit command to the running instance
+func askQuit() error {
+	var conn, err = net.Dial("unix", socketPath())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = fmt.Fprintln(conn, "quit")
+	return err
+}
+
+// quitCommand shuts gods down, e.g. when gods --replace takes over. The bar
+// is left as it is for the next instance to overwrite.
+func quitCommand(args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("usage: quit")
+	}
+	// let the reply go out first
+	time.AfterFunc(100*time.Millisecond, func() {
+		quitOnce.Do(func() { close(quitting) })
+	})
+	return "", nil
+}
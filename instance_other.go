@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd
+
+package main
+
+import "os"
+
+// flock always succeeds where there is no flock, so instances aren't kept
+// from running side by side there
+func flock(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commands can be sent to the running instance through the control socket,
+// e.g. `gods ctl pomodoro start`. A command returns the reply for the client.
+var commands = map[string]func(args []string) (string, error){
+	"pomodoro":      pomodoroCommand,
+	"timer":         timerCommand,
+	"alarm":         alarmCommand,
+	"break":         breakCommand,
+	"weather":       weatherCommand,
+	"telegram":      telegramCommand,
+	"homeassistant": homeAssistantCommand,
+	"dnd":           dndCommand,
+	"notifications": notificationsCommand,
+	"clipboard":     clipboardCommand,
+	"inhibit":       inhibitCommand,
+	"kbdlight":      kbdLightCommand,
+	"journal":       journalCommand,
+	"volume":        volumeCommand,
+	"hide":          visibilityCommand(true),
+	"show":          visibilityCommand(false),
+	"mode":          modeCommand,
+	"quit":          quitCommand,
+	"config":        configCommand,
+}
+
+// socketPath returns the location of the control socket
+func socketPath() string {
+	var dir = os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("gods-%d.sock", os.Getuid()))
+}
+
+// serveControl accepts commands on the control socket, one line per
+// connection
+func serveControl() {
+	os.Remove(socketPath())
+	var listener, err = net.Listen("unix", socketPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gods: control socket:", err)
+		return
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			continue
+		}
+		go handleControl(conn)
+	}
+}
+
+// handleControl runs the command read from conn and writes back its reply
+func handleControl(conn net.Conn) {
+	defer conn.Close()
+	var line, err = bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	var args = strings.Fields(line)
+	if len(args) == 0 {
+		fmt.Fprintln(conn, "error: empty command")
+		return
+	}
+
+	var command, ok = commands[args[0]]
+	if !ok {
+		fmt.Fprintf(conn, "error: unknown command %q\n", args[0])
+		return
+	}
+	reply, err := command(args[1:])
+	if err != nil {
+		fmt.Fprintln(conn, "error:", err)
+		return
+	}
+	fmt.Fprintln(conn, reply)
+}
+
+// errNotRunning is returned by request when no instance listens on the
+// control socket
+var errNotRunning = errors.New("not running")
+
+// request sends a command to the running instance and returns its reply
+func request(args []string) (string, error) {
+	var conn, err = net.Dial("unix", socketPath())
+	if err != nil {
+		return "", errNotRunning
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, strings.Join(args, " "))
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	reply = strings.TrimSuffix(reply, "\n")
+	if strings.HasPrefix(reply, "error: ") {
+		return "", errors.New(strings.TrimPrefix(reply, "error: "))
+	}
+	return reply, nil
+}
+
+// ctl sends a command to the running instance and prints its reply. It
+// returns the exit code for the client.
+func ctl(args []string) int {
+	var reply, err = request(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		return 1
+	}
+	if reply != "" {
+		fmt.Println(reply)
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	kbdLightSign = ""
+)
+
+// kbdLight returns the sysfs directory of the keyboard backlight
+func kbdLight() (string, error) {
+	var dirs, _ = filepath.Glob(sysPath("/sys/class/leds/*kbd_backlight*"))
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no keyboard backlight")
+	}
+	return dirs[0], nil
+}
+
+// readKbdLight returns the current and the maximum brightness
+func readKbdLight(dir string) (int, int, error) {
+	var values [2]int
+	for i, name := range []string{"brightness", "max_brightness"} {
+		var content, err = ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return 0, 0, err
+		}
+		if values[i], err = strconv.Atoi(strings.TrimSpace(string(content))); err != nil {
+			return 0, 0, err
+		}
+	}
+	return values[0], values[1], nil
+}
+
+// updateKbdLight shows the keyboard backlight level and hides itself if
+// there is no keyboard backlight or it is off
+func updateKbdLight() string {
+	var dir, err = kbdLight()
+	if err != nil {
+		return ""
+	}
+	brightness, max, err := readKbdLight(dir)
+	if err != nil {
+		return kbdLightSign + " ERR"
+	} else if brightness == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d/%d", kbdLightSign, brightness, max)
+}
+
+// kbdLightCommand changes the keyboard backlight level, writing to sysfs
+// needs permission through a udev rule or group
+func kbdLightCommand(args []string) (string, error) {
+	var dir, err = kbdLight()
+	if err != nil {
+		return "", err
+	}
+	brightness, max, err := readKbdLight(dir)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(args) == 1 && args[0] == "up":
+		brightness++
+	case len(args) == 1 && args[0] == "down":
+		brightness--
+	case len(args) == 2 && args[0] == "set":
+		if brightness, err = strconv.Atoi(args[1]); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("usage: kbdlight up|down|set <level>")
+	}
+	if brightness < 0 {
+		brightness = 0
+	} else if brightness > max {
+		brightness = max
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "brightness"), []byte(strconv.Itoa(brightness)), 0644)
+	refresh("kbdlight")
+	return "", err
+}
+
+// watchKbdLight shows level changes right away. Changes through the
+// keyboard's own keys are only noticed if the driver reports them in
+// brightness_hw_changed, otherwise they show up at the next update.
+func watchKbdLight() {
+	var dir, err = kbdLight()
+	if err == nil {
+		watch([]string{filepath.Join(dir, "brightness"), filepath.Join(dir, "brightness_hw_changed")},
+			func() { refresh("kbdlight") })
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	kernelSign = ""
+	rebootSign = ""
+)
+
+// updateKernel shows the running kernel release and a reboot icon once the
+// installed kernel or core libraries are newer than the running ones
+func updateKernel() string {
+	var release, err = ioutil.ReadFile(procPath("/proc/sys/kernel/osrelease"))
+	if err != nil {
+		return kernelSign + " ERR"
+	}
+	kernel := strings.TrimSpace(string(release))
+	if rebootRequired(kernel) {
+		return kernelSign + " " + kernel + " " + rebootSign
+	}
+	return kernelSign + " " + kernel
+}
+
+// rebootRequired looks for the markers package managers leave behind when an
+// upgrade only takes effect after the next boot
+func rebootRequired(kernel string) bool {
+	// apt (update-notifier) creates this after kernel and libc upgrades
+	if _, err := os.Stat("/var/run/reboot-required"); err == nil {
+		return true
+	}
+	// pacman and friends remove the modules of the replaced kernel
+	for _, modules := range []string{"/usr/lib/modules/", "/lib/modules/"} {
+		if _, err := os.Stat(modules); err != nil {
+			continue
+		}
+		if _, err := os.Stat(modules + kernel); os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	capsLockSign = "CAPS"
+	numLockSign  = "NUM"
+)
+
+// keyboardConfig names the keyboard layouts and selects the lock indicators
+type keyboardConfig struct {
+	// Names replaces the XKB layout names, e.g. {"us": "EN", "de(neo)": "NEO"}
+	Names map[string]string `json:"names,omitempty"`
+	// Locks are shown while on, "caps" and "num"
+	Locks []string `json:"locks"`
+}
+
+// lockIndicators are the locked modifiers of the usual keymaps, num lock is
+// bound to Mod2
+var lockIndicators = []struct {
+	name, sign string
+	mask       byte
+}{
+	{"caps", capsLockSign, 1 << 1},
+	{"num", numLockSign, 1 << 4},
+}
+
+var keyboard struct {
+	sync.Mutex
+	state   xkbState
+	layouts []string
+	err     error
+}
+
+// updateKeyboard shows the active XKB layout, which is followed through the
+// X keyboard extension
+func updateKeyboard() string {
+	keyboard.Lock()
+	defer keyboard.Unlock()
+	if keyboard.err != nil {
+		return keyboardSign + " ERR"
+	} else if keyboard.layouts == nil {
+		return ""
+	}
+	var layout = "default"
+	if keyboard.state.group < len(keyboard.layouts) {
+		layout = keyboard.layouts[keyboard.state.group]
+	}
+	if name, ok := cfg.Keyboard.Names[layout]; ok {
+		layout = name
+	}
+	return keyboardSign + " " + layout
+}
+
+// updateLocks shows the configured lock indicators that are on and hides
+// itself if there are none
+func updateLocks() string {
+	keyboard.Lock()
+	defer keyboard.Unlock()
+	var locks []string
+	for _, lock := range lockIndicators {
+		var wanted = false
+		for _, name := range cfg.Keyboard.Locks {
+			wanted = wanted || name == lock.name
+		}
+		if wanted && keyboard.state.lockedMods&lock.mask != 0 {
+			locks = append(locks, lock.sign)
+		}
+	}
+	if len(locks) == 0 {
+		return ""
+	}
+	return colorWarning + strings.Join(locks, " ") + colorNormal
+}
+
+// watchKeyboard follows the keyboard state, reconnecting to the X server
+// with exponential backoff
+func watchKeyboard() {
+	var poll = poller{}
+	for {
+		var err = followKeyboard()
+		keyboard.Lock()
+		keyboard.err = err
+		keyboard.Unlock()
+		refresh("keyboard")
+		poll.done(err)
+		time.Sleep(time.Until(poll.next))
+	}
+}
+
+// followKeyboard updates the keyboard state whenever it changes until the
+// connection to the X server breaks
+func followKeyboard() error {
+	var x, err = dialX()
+	if err != nil {
+		return err
+	}
+	defer x.conn.Close()
+	return x.watchXkbState(func(state xkbState) {
+		keyboard.Lock()
+		keyboard.state, keyboard.err = state, nil
+		// the layouts may have been changed with setxkbmap meanwhile
+		keyboard.layouts = xkbLayouts()
+		keyboard.Unlock()
+		refresh("keyboard")
+		refresh("locks")
+	})
+}
+
+// xkbLayouts lists the configured layouts in group order, variants are
+// added in parentheses like "de(neo)"
+func xkbLayouts() []string {
+	var out, _ = timedCommand("setxkbmap", "-query").Output()
+	var layouts, variants []string
+	for _, line := range strings.Split(string(out), "\n") {
+		var fields = strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "layout:":
+			layouts = strings.Split(fields[1], ",")
+		case "variant:":
+			variants = strings.Split(fields[1], ",")
+		}
+	}
+	for i := range layouts {
+		if i < len(variants) && variants[i] != "" {
+			layouts[i] += "(" + variants[i] + ")"
+		}
+	}
+	if layouts == nil {
+		layouts = []string{}
+	}
+	return layouts
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mailSign = ""
+
+	imapIdleTimeout = 25 * time.Minute // servers drop idle clients after 30 minutes
+)
+
+// mailConfig lists the IMAP accounts whose unread mails are counted
+type mailConfig struct {
+	Accounts []mailAccount `json:"accounts,omitempty"`
+	// Refresh is the polling interval for servers not supporting IDLE
+	Refresh duration `json:"refresh"`
+}
+
+// mailAccount is an IMAP account reached over TLS
+type mailAccount struct {
+	// Name is shown in front of the account's count
+	Name string `json:"name"`
+	// Server as host or host:port, the port defaults to 993
+	Server string `json:"server"`
+	User   string `json:"user"`
+	// PasswordCommand prints the password, e.g. "pass mail/work"
+	PasswordCommand string `json:"passwordcommand"`
+	// Mailbox defaults to INBOX
+	Mailbox string `json:"mailbox,omitempty"`
+}
+
+// mailbox is the last known state of an account
+type mailbox struct {
+	unseen int
+	err    error
+}
+
+var mail struct {
+	sync.Mutex
+	boxes map[string]mailbox
+}
+
+// updateMail shows the unread mails per account, it hides itself if there
+// are none
+func updateMail() string {
+	mail.Lock()
+	defer mail.Unlock()
+	var fields []string
+	for _, account := range cfg.Mail.Accounts {
+		var box, ok = mail.boxes[account.Name]
+		switch {
+		case !ok:
+		case box.err != nil:
+			fields = append(fields, account.Name+" ERR")
+		case box.unseen > 0:
+			fields = append(fields, fmt.Sprintf("%s %d", account.Name, box.unseen))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return mailSign + " " + strings.Join(fields, " ")
+}
+
+// watchMail keeps the unread counts of all accounts up to date, each one in
+// its own goroutine
+func watchMail() {
+	mail.boxes = map[string]mailbox{}
+	for _, account := range cfg.Mail.Accounts {
+		go watchMailbox(account)
+	}
+}
+
+// watchMailbox waits for changes using IDLE if the server supports it and
+// polls otherwise. It reconnects after errors with exponential backoff.
+func watchMailbox(account mailAccount) {
+	var poll = poller{refresh: cfg.Mail.Refresh.Duration}
+	for {
+		var err = watchIMAP(account)
+		poll.done(err)
+		if err != nil {
+			mail.Lock()
+			mail.boxes[account.Name] = mailbox{err: err}
+			mail.Unlock()
+			refresh("mail")
+		}
+		time.Sleep(time.Until(poll.next))
+	}
+}
+
+// watchIMAP logs in and reports the unread count. If the server supports
+// IDLE it keeps waiting for changes until the connection breaks, otherwise
+// it returns after the first count.
+func watchIMAP(account mailAccount) error {
+	var password, err = secret(account.PasswordCommand)
+	if err != nil {
+		return err
+	}
+	var server = account.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server += ":993"
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", server, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var c = &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	if _, err = c.readLine(); err != nil { // greeting
+		return err
+	}
+	if _, err = c.command("LOGIN %s %s", imapQuote(account.User), imapQuote(password)); err != nil {
+		return err
+	}
+	capabilities, err := c.command("CAPABILITY")
+	if err != nil {
+		return err
+	}
+	var mailboxName = account.Mailbox
+	if mailboxName == "" {
+		mailboxName = "INBOX"
+	}
+	if _, err = c.command("EXAMINE %s", imapQuote(mailboxName)); err != nil {
+		return err
+	}
+
+	for {
+		var lines, err = c.command("SEARCH UNSEEN")
+		if err != nil {
+			return err
+		}
+		var unseen = 0
+		for _, line := range lines {
+			if strings.HasPrefix(line, "* SEARCH") {
+				unseen += len(strings.Fields(line)) - 2
+			}
+		}
+		mail.Lock()
+		mail.boxes[account.Name] = mailbox{unseen: unseen}
+		mail.Unlock()
+		refresh("mail")
+
+		if !strings.Contains(strings.Join(capabilities, " "), "IDLE") {
+			c.command("LOGOUT")
+			return nil
+		}
+		if err = c.idle(); err != nil {
+			return err
+		}
+	}
+}
+
+// imapConn is a minimal IMAP client, good enough to count unread mails
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// command sends a tagged command and returns the untagged responses, it
+// fails unless the server answers OK
+func (c *imapConn) command(format string, args ...interface{}) ([]string, error) {
+	c.tag++
+	var tag = fmt.Sprintf("g%d", c.tag)
+	c.conn.SetDeadline(time.Now().Add(time.Minute))
+	if _, err := fmt.Fprintf(c.conn, tag+" "+format+"\r\n", args...); err != nil {
+		return nil, err
+	}
+	var untagged []string
+	for {
+		var line, err = c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			if !strings.HasPrefix(line, tag+" OK") {
+				return nil, fmt.Errorf("imap: %s", strings.TrimPrefix(line, tag+" "))
+			}
+			return untagged, nil
+		}
+		untagged = append(untagged, line)
+	}
+}
+
+// idle waits until the server reports a change of the mailbox or the idle
+// timeout is over
+func (c *imapConn) idle() error {
+	c.tag++
+	var tag = fmt.Sprintf("g%d", c.tag)
+	c.conn.SetDeadline(time.Now().Add(time.Minute))
+	if _, err := fmt.Fprintf(c.conn, "%s IDLE\r\n", tag); err != nil {
+		return err
+	}
+	if line, err := c.readLine(); err != nil {
+		return err
+	} else if !strings.HasPrefix(line, "+") {
+		return fmt.Errorf("imap: %s", line)
+	}
+
+	c.conn.SetDeadline(time.Now().Add(imapIdleTimeout))
+	for {
+		var line, err = c.readLine()
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			break
+		} else if err != nil {
+			return err
+		}
+		if strings.HasSuffix(line, "EXISTS") || strings.HasSuffix(line, "EXPUNGE") || strings.Contains(line, "FETCH") {
+			break
+		}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(time.Minute))
+	if _, err := fmt.Fprint(c.conn, "DONE\r\n"); err != nil {
+		return err
+	}
+	for {
+		var line, err = c.readLine()
+		if err != nil {
+			return err
+		} else if strings.HasPrefix(line, tag+" ") {
+			return nil
+		}
+	}
+}
+
+func (c *imapConn) readLine() (string, error) {
+	var line, err = c.r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// imapQuote quotes s as an IMAP string
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
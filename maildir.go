@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maildirConfig selects the local mail counted by the maildir module
+type maildirConfig struct {
+	// Maildirs are counted by the files in their new/ directory, globs like
+	// "~/Mail/*/INBOX" are allowed
+	Maildirs []string `json:"maildirs,omitempty"`
+	// NotmuchQuery is counted with notmuch instead, e.g. "tag:unread"
+	NotmuchQuery string `json:"notmuchquery,omitempty"`
+}
+
+// updateMaildir shows the number of new local mails and hides itself if
+// there are none
+func updateMaildir() string {
+	var count, err = countLocalMail()
+	if err != nil {
+		return mailSign + " ERR"
+	} else if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d", mailSign, count)
+}
+
+// countLocalMail asks notmuch if a query is configured and counts the files
+// in the new/ directories otherwise
+func countLocalMail() (int, error) {
+	if cfg.Maildir.NotmuchQuery != "" {
+		var out, err = timedCommand("notmuch", "count", cfg.Maildir.NotmuchQuery).Output()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(strings.TrimSpace(string(out)))
+	}
+
+	var count = 0
+	for _, dir := range maildirs() {
+		var files, err = ioutil.ReadDir(filepath.Join(dir, "new"))
+		if err != nil {
+			return 0, err
+		}
+		count += len(files)
+	}
+	return count, nil
+}
+
+// maildirs expands the configured maildir globs
+func maildirs() []string {
+	var dirs []string
+	for _, pattern := range cfg.Maildir.Maildirs {
+		var matches, _ = filepath.Glob(expandPath(pattern))
+		dirs = append(dirs, matches...)
+	}
+	return dirs
+}
+
+// watchMaildirs recounts as soon as mail is delivered to or read from one of
+// the maildirs
+func watchMaildirs() {
+	var paths []string
+	for _, dir := range maildirs() {
+		paths = append(paths, filepath.Join(dir, "new"), filepath.Join(dir, "cur"))
+	}
+	if len(paths) > 0 {
+		watch(paths, func() { refresh("maildir") })
+	}
+}
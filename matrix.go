@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	matrixSign = ""
+
+	matrixSyncTimeout = 30 * time.Second
+)
+
+// matrixConfig is the account whose unread notifications are counted
+type matrixConfig struct {
+	// Homeserver is the client API base URL, e.g. https://matrix.org
+	Homeserver  string `json:"homeserver,omitempty"`
+	AccessToken string `json:"accesstoken,omitempty" secret:"true"`
+	// TokenCommand prints the access token instead
+	TokenCommand string `json:"tokencommand,omitempty"`
+}
+
+// matrixFilter leaves out everything but the unread counts of the rooms
+const matrixFilter = `{"presence":{"types":[]},"account_data":{"types":[]},` +
+	`"room":{"timeline":{"limit":1},"state":{"types":[]},"ephemeral":{"types":[]},"account_data":{"types":[]}}}`
+
+// matrixRoom are the unread counts of a joined room
+type matrixRoom struct {
+	Notifications int `json:"notification_count"`
+	Highlights    int `json:"highlight_count"`
+}
+
+var matrix struct {
+	sync.Mutex
+	rooms  map[string]matrixRoom
+	synced bool
+	err    error
+}
+
+var matrixClient = &http.Client{Timeout: matrixSyncTimeout + 30*time.Second}
+
+// updateMatrix shows the number of unread notifications and, highlighted,
+// mentions across all joined rooms. It hides itself if there are none.
+func updateMatrix() string {
+	matrix.Lock()
+	defer matrix.Unlock()
+	if matrix.err != nil && !matrix.synced {
+		return matrixSign + " ERR"
+	}
+	var notifications, highlights = 0, 0
+	for _, room := range matrix.rooms {
+		notifications += room.Notifications
+		highlights += room.Highlights
+	}
+	switch {
+	case highlights > 0:
+		return fmt.Sprintf("%s %d %s@%d%s", matrixSign, notifications, colorWarning, highlights, colorNormal)
+	case notifications > 0:
+		return fmt.Sprintf("%s %d", matrixSign, notifications)
+	}
+	return ""
+}
+
+// watchMatrix long-polls the sync API, so new messages show up right away.
+// After errors it retries with exponential backoff.
+func watchMatrix() {
+	if cfg.Matrix.Homeserver == "" {
+		return
+	}
+	matrix.rooms = map[string]matrixRoom{}
+	var poll = poller{}
+	var since = ""
+	for {
+		var next, err = syncMatrix(since)
+		matrix.Lock()
+		matrix.err = err
+		matrix.synced = matrix.synced || err == nil
+		matrix.Unlock()
+		refresh("matrix")
+
+		poll.done(err)
+		if err == nil {
+			since = next
+		} else {
+			time.Sleep(time.Until(poll.next))
+		}
+	}
+}
+
+// syncMatrix waits for changes since the given batch and merges the unread
+// counts into the known rooms. It returns the token for the next sync.
+func syncMatrix(since string) (string, error) {
+	var token = cfg.Matrix.AccessToken
+	if cfg.Matrix.TokenCommand != "" {
+		var err error
+		if token, err = secret(cfg.Matrix.TokenCommand); err != nil {
+			return "", err
+		}
+	}
+
+	var query = url.Values{"filter": {matrixFilter}}
+	if since != "" {
+		query.Set("since", since)
+		query.Set("timeout", fmt.Sprint(matrixSyncTimeout.Milliseconds()))
+	}
+	var req, err = http.NewRequest("GET", cfg.Matrix.Homeserver+"/_matrix/client/v3/sync?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := matrixClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		NextBatch string `json:"next_batch"`
+		Rooms     struct {
+			Join map[string]struct {
+				Unread matrixRoom `json:"unread_notifications"`
+			}
+			Leave map[string]json.RawMessage
+		}
+	}
+	if err = decodeJSON(resp, &result); err != nil {
+		return "", err
+	}
+
+	matrix.Lock()
+	defer matrix.Unlock()
+	for id, room := range result.Rooms.Join {
+		matrix.rooms[id] = room.Unread
+	}
+	for id := range result.Rooms.Leave {
+		delete(matrix.rooms, id)
+	}
+	return result.NextBatch, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// metrics are the numeric values behind the modules' texts, like "cpu" or
+// "battery", as last recorded by the modules
+var metrics struct {
+	sync.Mutex
+	values map[string]float64
+}
+
+// record stores a metric's new value and runs the rules watching it
+func record(name string, value float64) {
+	metrics.Lock()
+	if metrics.values == nil {
+		metrics.values = map[string]float64{}
+	}
+	metrics.values[name] = value
+	metrics.Unlock()
+	checkRules(name, value)
+}
+
+// snapshot returns the current values of the wanted metrics, all of them if
+// none are listed, along with their names in order
+func snapshot(wanted []string) ([]string, map[string]float64) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	var names []string
+	var values = map[string]float64{}
+	for name, value := range metrics.values {
+		var selected = len(wanted) == 0
+		for _, metric := range wanted {
+			selected = selected || metric == name
+		}
+		if selected {
+			names = append(names, name)
+			values[name] = value
+		}
+	}
+	sort.Strings(names)
+	return names, values
+}
+
+// ruleConfig runs a command when a metric crosses a threshold
+type ruleConfig struct {
+	Metric string `json:"metric"`
+	// Above or Below is the threshold, only one of them is used
+	Above *float64 `json:"above,omitempty"`
+	Below *float64 `json:"below,omitempty"`
+	// For is how long the threshold has to stay crossed, so single spikes
+	// are ignored
+	For duration `json:"for"`
+	// Hysteresis is how far the value has to go back before the rule clears
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	// Command runs once when the threshold is crossed, Clear once the value
+	// is back. They get $METRIC, $VALUE and $THRESHOLD.
+	Command string `json:"command,omitempty"`
+	Clear   string `json:"clear,omitempty"`
+	// Notify is the summary of a desktop notification raised along with
+	// the command
+	Notify string `json:"notify,omitempty"`
+}
+
+// ruleState tracks a rule across the values of its metric
+type ruleState struct {
+	crossed time.Time // when the threshold was crossed, zero if it isn't
+	active  bool      // the command ran and the rule didn't clear yet
+}
+
+// ruleStates are the states of the rules, by index
+var ruleStates = map[int]*ruleState{}
+
+// checkRules runs the commands of the rules for the metric whose threshold
+// was crossed for long enough, or which cleared
+func checkRules(name string, value float64) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	for i, rule := range cfg.Rules {
+		if rule.Metric != name {
+			continue
+		}
+		var state, ok = ruleStates[i]
+		if !ok {
+			state = &ruleState{}
+			ruleStates[i] = state
+		}
+
+		var threshold, crossed, cleared = 0.0, false, true
+		if rule.Above != nil {
+			threshold = *rule.Above
+			crossed, cleared = value > threshold, value <= threshold-rule.Hysteresis
+		} else if rule.Below != nil {
+			threshold = *rule.Below
+			crossed, cleared = value < threshold, value >= threshold+rule.Hysteresis
+		}
+		if !crossed {
+			state.crossed = time.Time{}
+		} else if state.crossed.IsZero() {
+			state.crossed = time.Now()
+		}
+
+		var env = []string{"METRIC=" + name, fmt.Sprintf("VALUE=%g", value), fmt.Sprintf("THRESHOLD=%g", threshold)}
+		switch {
+		case !state.active && crossed && time.Since(state.crossed) >= rule.For.Duration:
+			state.active = true
+			runHook(rule.Command, env...)
+			if rule.Notify != "" {
+				notify("critical", rule.Notify, fmt.Sprintf("%s is %g", name, value))
+			}
+		case state.active && cleared:
+			state.active = false
+			runHook(rule.Clear, env...)
+		}
+	}
+}
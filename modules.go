@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// module is a single field of the status bar
+type module struct {
+	name     string
+	interval time.Duration
+	jitter   time.Duration
+	update   func() string
+	off      bool // not run unless cfg.Enable or the carousel names it
+
+	text   string        // last result of update, guarded by statusMu
+	hidden bool          // left out of the bar, guarded by statusMu
+	wake   chan struct{} // triggers an update before the interval is over
+}
+
+var statusMu sync.Mutex
+
+// redraws signals the main loop that the bar has to be drawn again
+var redraws = make(chan struct{}, 1)
+
+// redraw requests drawing the bar again
+func redraw() {
+	select {
+	case redraws <- struct{}{}:
+	default: // a redraw is pending already
+	}
+}
+
+// run keeps the module's text up to date and requests a redraw whenever it
+// differs from before. Updates are aligned to multiples of the interval on the
+// wall clock, so e.g. a clock updated every second ticks right at the start of
+// each second. Slots missed by a slow update are skipped rather than caught up.
+func (m *module) run() {
+	for {
+		var profileChanged = profileChanges()
+		var text = ""
+		if !profileHides(m.name) {
+			text = m.update()
+		}
+		statusMu.Lock()
+		if text != m.text {
+			m.text = text
+			redraw()
+		}
+		statusMu.Unlock()
+
+		var at time.Time // never reached for an interval of 0
+		if interval := profileInterval(m.interval); interval > 0 {
+			at = time.Now().Truncate(interval).Add(interval)
+			if m.jitter > 0 {
+				at = at.Add(time.Duration(rand.Int63n(int64(m.jitter))))
+			}
+		}
+		m.wait(at, profileChanged)
+	}
+}
+
+// suspendCheck is how often a waiting module looks at the wall clock
+const suspendCheck = 5 * time.Second
+
+// wait blocks until the wall clock reaches at, the module is woken up or the
+// profile changes. Timers run on the monotonic clock, which stops while the
+// machine is suspended, so the wall clock is checked every suspendCheck to
+// update right after a resume instead of being late by the time asleep.
+func (m *module) wait(at time.Time, profileChanged <-chan struct{}) {
+	for {
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if !at.IsZero() {
+			var wait = time.Until(at.Round(0)) // compare wall clocks
+			if wait <= 0 {
+				return
+			}
+			if wait > suspendCheck {
+				wait = suspendCheck
+			}
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		var woken = false
+		select {
+		case <-timeout:
+		case <-m.wake:
+			woken = true
+		case <-profileChanged:
+			woken = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if woken {
+			return
+		}
+	}
+}
+
+// refresh updates the named module right away, e.g. after its state was
+// changed through the control socket
+func refresh(name string) {
+	for _, m := range modules {
+		if m.name == name {
+			select {
+			case m.wake <- struct{}{}:
+			default: // an update is pending already
+			}
+		}
+	}
+}
+
+// enabled reports whether the named module is part of the bar
+func enabled(name string) bool {
+	for _, m := range modules {
+		if m.name == name {
+			return !m.off
+		}
+	}
+	return false
+}
+
+// enableModules turns on the modules that are off by default but named by
+// cfg.Enable or the carousel
+func enableModules() {
+	for _, m := range modules {
+		for _, name := range cfg.Enable {
+			m.off = m.off && m.name != name
+		}
+		m.off = m.off && !inCarousel(m.name)
+	}
+}
+
+// visibilityCommand hides modules from the bar or shows them again, e.g.
+// `hide weather ticker` while sharing the screen
+func visibilityCommand(hide bool) func(args []string) (string, error) {
+	return func(args []string) (string, error) {
+		if len(args) == 0 {
+			return "", fmt.Errorf("usage: hide|show <module>...")
+		}
+		for _, name := range args {
+			if !enabled(name) {
+				return "", fmt.Errorf("unknown module %q, or off and missing from enable", name)
+			}
+		}
+		statusMu.Lock()
+		for _, m := range modules {
+			for _, name := range args {
+				if m.name == name {
+					m.hidden = hide
+				}
+			}
+		}
+		statusMu.Unlock()
+		redraw()
+		return "", nil
+	}
+}
+
+// slowCommandTimeout is how long package managers and the like may take,
+// e.g. while refreshing their metadata
+const slowCommandTimeout = 5 * time.Minute
+
+// timedCommand is exec.Command for commands whose output a module waits for.
+// They have to finish within cfg.CommandTimeout, so a hanging helper can't
+// block a module forever, and are killed once the time is up.
+func timedCommand(name string, args ...string) *exec.Cmd {
+	return commandWithin(cfg.CommandTimeout.Duration, name, args...)
+}
+
+// commandWithin is timedCommand with a timeout of its own
+func commandWithin(timeout time.Duration, name string, args ...string) *exec.Cmd {
+	// the deadline releases the context, the caller can't tell when the
+	// command is done with it
+	var ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	_ = cancel
+	var cmd = exec.CommandContext(ctx, name, args...)
+	// children left behind may keep the output open
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// runHook starts a user supplied command through sh in the background, env
+// is added to its environment. An empty command is ignored. Hooks may run
+// for as long as they like, e.g. a terminal opened by a click, so they get
+// no timeout and their own process group.
+func runHook(command string, env ...string) {
+	if command == "" {
+		return
+	}
+	var cmd = exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	ownProcessGroup(cmd)
+	if cmd.Start() == nil {
+		go cmd.Wait()
+	}
+}
+
+// render joins the texts of all modules, modules with an empty text are
+// hidden
+func render() string {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	var fields = []string{""}
+	for _, m := range modules {
+		switch {
+		case m.text == "" || m.hidden || inCarousel(m.name):
+		case cfg.Click.StatusCmd:
+			fields = append(fields, clickText(m.name, m.text))
+		default:
+			fields = append(fields, m.text)
+		}
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// poller rate limits polling a remote service: it is due once the refresh
+// interval passed since the last successful poll, failures are retried with
+// exponential backoff
+type poller struct {
+	refresh  time.Duration
+	next     time.Time
+	failures int
+}
+
+// maxBackoff is the longest a poller waits after repeated failures
+const maxBackoff = time.Hour
+
+// due reports whether the service should be polled now
+func (p *poller) due() bool {
+	return !time.Now().Before(p.next)
+}
+
+// done records the outcome of a poll and schedules the next one
+func (p *poller) done(err error) {
+	if err == nil {
+		p.failures = 0
+		p.next = time.Now().Add(p.refresh)
+		return
+	}
+	p.failures++
+	var wait = maxBackoff
+	if p.failures < 8 {
+		wait = 30 * time.Second << uint(p.failures-1)
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	p.next = time.Now().Add(wait)
+}
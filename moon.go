@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// moonSigns are the phases from new moon over full moon back to new moon
+var moonSigns = []string{"🌑", "🌒", "🌓", "🌔", "🌕", "🌖", "🌗", "🌘"}
+
+const (
+	synodicMonth = 29.530588853 // days from new moon to new moon
+)
+
+// knownNewMoon is the reference the current phase is computed from
+var knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// updateMoon shows the current moon phase and its illumination, computed
+// locally from the mean length of the lunar cycle
+func updateMoon() string {
+	var age = math.Mod(time.Since(knownNewMoon).Hours()/24, synodicMonth) / synodicMonth
+	var phase = int(math.Floor(age*8+0.5)) % 8
+	var illumination = (1 - math.Cos(2*math.Pi*age)) / 2 * 100
+	return fmt.Sprintf("%s %d%%", moonSigns[phase], int(math.Round(illumination)))
+}
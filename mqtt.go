@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const mqttKeepAlive = time.Minute
+
+// mqttConfig maps the topics of an MQTT broker to fields of the bar
+type mqttConfig struct {
+	// Broker is an URL like tcp://host:1883 or tls://host:8883
+	Broker string `json:"broker,omitempty"`
+	User   string `json:"user,omitempty"`
+	// PasswordCommand prints the password, e.g. "pass mqtt/gods"
+	PasswordCommand string      `json:"passwordcommand,omitempty"`
+	Fields          []mqttField `json:"fields,omitempty"`
+}
+
+// mqttField shows the last message published on a topic, which may contain
+// the + and # wildcards
+type mqttField struct {
+	Topic string `json:"topic"`
+	// Format is applied to the message with fmt, e.g. " %s°C"
+	Format string `json:"format,omitempty"`
+	// Values replace messages before formatting, e.g. {"ON": "door open"}.
+	// A message replaced by "" hides the field.
+	Values map[string]string `json:"values,omitempty"`
+}
+
+var mqtt struct {
+	sync.Mutex
+	messages map[int]string // by field
+	err      error
+}
+
+// updateMQTT shows the last message of every field, fields without one yet
+// are left out
+func updateMQTT() string {
+	mqtt.Lock()
+	defer mqtt.Unlock()
+	if mqtt.err != nil && len(mqtt.messages) == 0 {
+		return "mqtt ERR"
+	}
+	var fields []string
+	for i, field := range cfg.MQTT.Fields {
+		var message, ok = mqtt.messages[i]
+		if replaced, found := field.Values[message]; found {
+			message = replaced
+		}
+		if !ok || message == "" {
+			continue
+		}
+		if field.Format != "" {
+			message = fmt.Sprintf(field.Format, message)
+		}
+		fields = append(fields, message)
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// watchMQTT stays subscribed to the topics, reconnecting with exponential
+// backoff
+func watchMQTT() {
+	if cfg.MQTT.Broker == "" {
+		return
+	}
+	mqtt.messages = map[int]string{}
+	var poll = poller{}
+	for {
+		var err = subscribeMQTT()
+		mqtt.Lock()
+		mqtt.err = err
+		mqtt.Unlock()
+		refresh("mqtt")
+		poll.done(err)
+		time.Sleep(time.Until(poll.next))
+	}
+}
+
+// subscribeMQTT connects to the broker and records the messages published
+// on the topics of the fields until the connection breaks
+func subscribeMQTT() error {
+	var broker, err = url.Parse(cfg.MQTT.Broker)
+	if err != nil {
+		return err
+	}
+	var dialer = &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	switch broker.Scheme {
+	case "tcp", "mqtt":
+		conn, err = dialer.Dial("tcp", broker.Host)
+	case "tls", "ssl", "mqtts":
+		conn, err = tls.DialWithDialer(dialer, "tcp", broker.Host, nil)
+	default:
+		return fmt.Errorf("mqtt: unknown scheme %q", broker.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var r = bufio.NewReader(conn)
+
+	// CONNECT with a clean session
+	var flags byte = 0x02
+	var payload = mqttString(fmt.Sprintf("gods-%d", os.Getpid()))
+	if cfg.MQTT.User != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(cfg.MQTT.User)...)
+	}
+	// MQTT 3.1.1 allows a password only along with a user name
+	if cfg.MQTT.User != "" && cfg.MQTT.PasswordCommand != "" {
+		var password, err = secret(cfg.MQTT.PasswordCommand)
+		if err != nil {
+			return err
+		}
+		flags |= 0x40
+		payload = append(payload, mqttString(password)...)
+	}
+	var connect = append(mqttString("MQTT"), 4, flags, 0, 0)
+	binary.BigEndian.PutUint16(connect[len(connect)-2:], uint16(mqttKeepAlive.Seconds()))
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	if err = mqttWrite(conn, 0x10, append(connect, payload...)); err != nil {
+		return err
+	}
+	kind, body, err := mqttRead(r)
+	if err != nil {
+		return err
+	} else if kind != 0x20 || len(body) < 2 || body[1] != 0 {
+		return fmt.Errorf("mqtt: connection refused")
+	}
+
+	// SUBSCRIBE to all topics with QoS 0
+	var subscribe = []byte{0, 1}
+	for _, field := range cfg.MQTT.Fields {
+		subscribe = append(append(subscribe, mqttString(field.Topic)...), 0)
+	}
+	if err = mqttWrite(conn, 0x82, subscribe); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	var done = make(chan struct{})
+	defer close(done)
+	go func() {
+		var ping = time.NewTicker(mqttKeepAlive / 2)
+		defer ping.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ping.C:
+				mqttWrite(conn, 0xc0, nil)
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(mqttKeepAlive))
+		var kind, body, err = mqttRead(r)
+		if err != nil {
+			return err
+		}
+		if kind&0xf0 != 0x30 || len(body) < 2 {
+			continue // SUBACK, PINGRESP
+		}
+		var length = int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+length {
+			return fmt.Errorf("mqtt: bad publish packet")
+		}
+		var topic, message = string(body[2 : 2+length]), body[2+length:]
+		if qos := kind >> 1 & 3; qos > 0 && len(message) >= 2 {
+			message = message[2:] // packet id
+		}
+
+		mqtt.Lock()
+		for i, field := range cfg.MQTT.Fields {
+			if mqttMatch(field.Topic, topic) {
+				mqtt.messages[i] = strings.TrimSpace(string(message))
+			}
+		}
+		mqtt.Unlock()
+		refresh("mqtt")
+	}
+}
+
+// mqttMatch reports whether a topic matches a filter with wildcards
+func mqttMatch(filter, topic string) bool {
+	var levels = strings.Split(topic, "/")
+	for i, level := range strings.Split(filter, "/") {
+		switch {
+		case level == "#":
+			return true
+		case i >= len(levels):
+			return false
+		case level != "+" && level != levels[i]:
+			return false
+		}
+	}
+	return len(strings.Split(filter, "/")) == len(levels)
+}
+
+// mqttString encodes s with its length in front
+func mqttString(s string) []byte {
+	var b = make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// mqttWrite sends a packet of the given type and flags
+func mqttWrite(w io.Writer, kind byte, body []byte) error {
+	var packet = []byte{kind}
+	var length = len(body)
+	for {
+		var digit = byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	var _, err = w.Write(append(packet, body...))
+	return err
+}
+
+// mqttRead receives a packet and returns its type and flags and its body
+func mqttRead(r *bufio.Reader) (byte, []byte, error) {
+	var kind, err = r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var length, shift = 0, uint(0)
+	for {
+		var digit, err = r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		} else if shift += 7; shift > 21 {
+			return 0, nil, fmt.Errorf("mqtt: bad packet length")
+		}
+	}
+	var body = make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return kind, body, err
+}
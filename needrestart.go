@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	restartSign = ""
+)
+
+// updateRestart shows how many services still use libraries that were
+// replaced by an upgrade and need a restart to load the new ones, naming
+// them in the expanded layout. It hides itself if there are none. Without
+// root only the user's own processes can be checked.
+func updateRestart() string {
+	var services, err = staleServices()
+	if err != nil {
+		return restartSign + " ERR"
+	}
+	if len(services) == 0 {
+		return ""
+	} else if expanded() {
+		return fmt.Sprintf("%s%s %s%s", colorWarning, restartSign, strings.Join(services, " "), colorNormal)
+	}
+	return fmt.Sprintf("%s%s %d%s", colorWarning, restartSign, len(services), colorNormal)
+}
+
+// staleServices lists the services with a process that maps a deleted
+// shared library, processes outside of a service are named by their command
+func staleServices() ([]string, error) {
+	var dirs, err = filepath.Glob(procPath("/proc/[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var seen = map[string]bool{}
+	for _, dir := range dirs {
+		if !mapsDeletedLibrary(filepath.Join(dir, "maps")) {
+			continue
+		}
+		if name := processService(dir); name != "" {
+			seen[name] = true
+		}
+	}
+	var services = make([]string, 0, len(seen))
+	for name := range seen {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// mapsDeletedLibrary reports whether the memory maps of a process contain a
+// shared library that has been deleted, like
+// "7f2c... r-xp 00000000 fe:01 1234 /usr/lib/libssl.so.3 (deleted)". Maps
+// that can't be read, e.g. of other users' processes, count as fine.
+func mapsDeletedLibrary(path string) bool {
+	var file, err = os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var line = scanner.Text()
+		if !strings.HasSuffix(line, " (deleted)") {
+			continue
+		}
+		var i = strings.IndexByte(line, '/')
+		if i >= 0 && strings.Contains(line[i:], ".so") && !strings.HasPrefix(line[i:], "/memfd:") {
+			return true
+		}
+	}
+	return false
+}
+
+// processService returns the systemd unit the process in dir belongs to, or
+// its command if it isn't part of a service
+func processService(dir string) string {
+	var cgroup, _ = ioutil.ReadFile(filepath.Join(dir, "cgroup"))
+	for _, line := range strings.Split(string(cgroup), "\n") {
+		// e.g. "0::/user.slice/user-1000.slice/user@1000.service/app.slice/pipewire.service"
+		var parts = strings.Split(line, "/")
+		for i := len(parts) - 1; i >= 0; i-- {
+			if strings.HasSuffix(parts[i], ".service") && !strings.HasPrefix(parts[i], "user@") {
+				return parts[i]
+			}
+		}
+	}
+	var comm, _ = ioutil.ReadFile(filepath.Join(dir, "comm"))
+	return strings.TrimSpace(string(comm))
+}
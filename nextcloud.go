@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	nextcloudSign     = ""
+	nextcloudSyncSign = ""
+)
+
+// nextcloudSockets are where the Nextcloud desktop client and the ownCloud
+// client it is forked from listen for the file manager integrations
+var nextcloudSockets = []string{
+	"$XDG_RUNTIME_DIR/Nextcloud/socket",
+	"$XDG_RUNTIME_DIR/ownCloud/socket",
+}
+
+// updateNextcloud shows the state of the desktop client's sync folders: the
+// icon alone while they are up to date, a sync icon while syncing and the
+// warning or urgent color on problems. It hides itself while the client
+// isn't running.
+func updateNextcloud() string {
+	var status = ""
+	for _, socket := range nextcloudSockets {
+		var err error
+		if status, err = nextcloudStatus(os.ExpandEnv(socket)); err == nil {
+			break
+		}
+	}
+	switch status {
+	case "":
+		return ""
+	case "ERROR":
+		return colorUrgent + nextcloudSign + " ERR" + colorNormal
+	case "WARNING":
+		return colorWarning + nextcloudSign + colorNormal
+	case "SYNC", "NEW":
+		return nextcloudSign + " " + nextcloudSyncSign
+	}
+	return nextcloudSign
+}
+
+// nextcloudRank orders the folder states, the worst one is shown
+var nextcloudRank = map[string]int{"OK": 1, "NEW": 2, "SYNC": 2, "WARNING": 3, "ERROR": 4}
+
+// nextcloudStatus asks the client listening on socket for the status of
+// each sync folder and returns the worst one. The client announces its
+// folders right after connecting with lines like "REGISTER_PATH:/home/me/
+// Nextcloud" and answers with e.g. "STATUS:SYNC:/home/me/Nextcloud".
+func nextcloudStatus(socket string) (string, error) {
+	var conn, err = net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	var pending = map[string]bool{}
+	var worst = ""
+	for scanner := bufio.NewScanner(conn); scanner.Scan(); {
+		var line = scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "REGISTER_PATH:"):
+			var path = strings.TrimPrefix(line, "REGISTER_PATH:")
+			pending[path] = true
+			fmt.Fprintf(conn, "RETRIEVE_FOLDER_STATUS:%s\n", path)
+		case strings.HasPrefix(line, "STATUS:"):
+			var fields = strings.SplitN(strings.TrimPrefix(line, "STATUS:"), ":", 2)
+			if len(fields) != 2 || !pending[fields[1]] {
+				continue
+			}
+			delete(pending, fields[1])
+			// shared folders add e.g. "+SWM"
+			var state = strings.SplitN(fields[0], "+", 2)[0]
+			if nextcloudRank[state] > nextcloudRank[worst] {
+				worst = state
+			}
+			if len(pending) == 0 {
+				return worst, nil
+			}
+		}
+	}
+	if worst == "" {
+		return "", fmt.Errorf("nextcloud: no sync folder status")
+	}
+	return worst, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	notificationSign = ""
+	dndSign          = ""
+)
+
+// updateDND shows an icon while dunst holds back notifications and hides
+// itself otherwise or if dunst isn't installed
+func updateDND() string {
+	if _, err := exec.LookPath("dunstctl"); err != nil {
+		return ""
+	}
+	var out, err = timedCommand("dunstctl", "is-paused").Output()
+	if err != nil {
+		return dndSign + " ERR"
+	} else if strings.TrimSpace(string(out)) != "true" {
+		return ""
+	}
+	return colorWarning + dndSign + colorNormal
+}
+
+// dndCommand pauses or resumes dunst's notifications
+func dndCommand(args []string) (string, error) {
+	var state = strings.Join(args, " ")
+	switch state {
+	case "toggle", "on", "off":
+	default:
+		return "", fmt.Errorf("usage: dnd toggle|on|off")
+	}
+	var paused = map[string]string{"toggle": "toggle", "on": "true", "off": "false"}[state]
+	if out, err := timedCommand("dunstctl", "set-paused", paused).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("dunstctl: %s", strings.TrimSpace(string(out)))
+	}
+	refresh("dnd")
+	return "", nil
+}
+
+// updateNotifications shows the notifications held back by do not disturb
+// and those in dunst's history, i.e. seen while you were away. It hides
+// itself if there are none.
+func updateNotifications() string {
+	if _, err := exec.LookPath("dunstctl"); err != nil {
+		return ""
+	}
+	var waiting, errWaiting = dunstCount("waiting")
+	var history, errHistory = dunstCount("history")
+	switch {
+	case errWaiting != nil || errHistory != nil:
+		return notificationSign + " ERR"
+	case waiting > 0:
+		return fmt.Sprintf("%s %d+%d", notificationSign, history, waiting)
+	case history > 0:
+		return fmt.Sprintf("%s %d", notificationSign, history)
+	}
+	return ""
+}
+
+// dunstCount asks dunst for the number of waiting, displayed or history
+// notifications
+func dunstCount(which string) (int, error) {
+	var out, err = timedCommand("dunstctl", "count", which).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// notificationsCommand clears dunst's history
+func notificationsCommand(args []string) (string, error) {
+	if len(args) != 1 || args[0] != "clear" {
+		return "", fmt.Errorf("usage: notifications clear")
+	}
+	if out, err := timedCommand("dunstctl", "history-clear").CombinedOutput(); err != nil {
+		return "", fmt.Errorf("dunstctl: %s", strings.TrimSpace(string(out)))
+	}
+	refresh("notifications")
+	return "", nil
+}
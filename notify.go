@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// notifyConfig selects the modules raising desktop notifications
+type notifyConfig struct {
+	// Modules may notify, e.g. ["power", "wifi", "updates"]
+	Modules []string `json:"modules,omitempty"`
+	// RateLimit is the least time between two notifications of a module
+	RateLimit duration `json:"ratelimit"`
+	// BatteryLow is the percentage the power module warns at
+	BatteryLow int `json:"batterylow"`
+}
+
+var notifications struct {
+	sync.Mutex
+	active map[string]bool      // conditions currently met, by module
+	last   map[string]time.Time // when a module notified last
+}
+
+// notifyWhen raises a notification once a module's condition becomes true.
+// It stays quiet while the condition holds and for the rate limit after the
+// module's last notification.
+func notifyWhen(module string, condition bool, urgency, summary, body string) {
+	notifications.Lock()
+	if notifications.active == nil {
+		notifications.active = map[string]bool{}
+	}
+	var became = condition && !notifications.active[module]
+	notifications.active[module] = condition
+	notifications.Unlock()
+	if became && notifyAllowed(module) {
+		notify(urgency, summary, body)
+	}
+}
+
+// notifyAllowed reports whether notify.modules enables a module and its rate
+// limit has passed, and counts the notification the caller is about to send.
+func notifyAllowed(module string) bool {
+	var allowed = false
+	for _, name := range cfg.Notify.Modules {
+		allowed = allowed || name == module
+	}
+	if !allowed {
+		return false
+	}
+
+	notifications.Lock()
+	defer notifications.Unlock()
+	if notifications.last == nil {
+		notifications.last = map[string]time.Time{}
+	}
+	if time.Since(notifications.last[module]) < cfg.Notify.RateLimit.Duration {
+		return false
+	}
+	notifications.last[module] = time.Now()
+	return true
+}
+
+// notify shows a desktop notification through org.freedesktop.Notifications,
+// urgency is "low", "normal" or "critical"
+func notify(urgency, summary, body string) {
+	var cmd = timedCommand("notify-send", "--app-name=gods", "--urgency="+urgency, summary, body)
+	if cmd.Start() == nil {
+		go cmd.Wait()
+	}
+}
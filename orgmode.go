@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	orgSign = ""
+)
+
+// orgConfig tells where the org agenda is taken from. The agenda is expected
+// in the CSV format of org-batch-agenda-csv, either printed by Command or
+// written to File by some other job.
+type orgConfig struct {
+	Command string   `json:"command,omitempty"`
+	File    string   `json:"file,omitempty"`
+	Refresh duration `json:"refresh"`
+}
+
+var org struct {
+	items     []event
+	lastFetch time.Time
+}
+
+// updateOrg shows the next timed item of the org agenda, using the lookahead
+// and colors of the agenda module
+func updateOrg() string {
+	if time.Since(org.lastFetch) > cfg.Org.Refresh.Duration {
+		if items, err := readOrgAgenda(); err == nil {
+			org.items = items
+			org.lastFetch = time.Now()
+		} else if org.lastFetch.IsZero() {
+			return orgSign + " ERR"
+		}
+	}
+
+	var now = time.Now()
+	var next event
+	for _, item := range org.items {
+		if item.start.After(now) && (next.start.IsZero() || item.start.Before(next.start)) {
+			next = item
+		}
+	}
+	if next.start.IsZero() || next.start.Sub(now) > cfg.Agenda.Lookahead.Duration {
+		return ""
+	}
+	return formatEvent(orgSign, next, now)
+}
+
+// readOrgAgenda parses the timed items of the agenda export
+func readOrgAgenda() ([]event, error) {
+	var data []byte
+	var err error
+	if cfg.Org.File != "" {
+		data, err = ioutil.ReadFile(expandPath(cfg.Org.File))
+	} else {
+		data, err = commandWithin(slowCommandTimeout, "sh", "-c", cfg.Org.Command).Output()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r = csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var items []event
+	for _, record := range records {
+		// category,head,type,todo,tags,date,time,extra,...
+		if len(record) < 7 || len(record[6]) < 5 {
+			continue
+		}
+		var start, err = time.ParseInLocation("2006-1-2 15:04", record[5]+" "+record[6][:5], time.Local)
+		if err != nil {
+			// single digit hours are written as "9:00"
+			start, err = time.ParseInLocation("2006-1-2 15:04", record[5]+" "+record[6][:4], time.Local)
+		}
+		if err == nil {
+			items = append(items, event{summary: record[1], start: start})
+		}
+	}
+	return items, nil
+}
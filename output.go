@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// outputs draw the rendered bar, cfg.Output selects one of them
+var outputs = map[string]func(status string) error{
+	// xsetroot sets the name of the root window, which dwm shows
+	"xsetroot": func(status string) error {
+		return timedCommand("xsetroot", "-name", status).Run()
+	},
+	// stdout prints a line per update, e.g. for lemonbar or i3bar's simple
+	// protocol
+	"stdout": func(status string) error {
+		_, err := fmt.Println(plainText(status))
+		return err
+	},
+	// tmux sets the right side of its status line
+	"tmux": func(status string) error {
+		status = tmuxColors.Replace(strings.Replace(status, "#", "##", -1))
+		return timedCommand("tmux", "set-option", "-g", "status-right", plainText(status)).Run()
+	},
+}
+
+// tmuxColors turns the statuscolors escapes into tmux styles
+var tmuxColors = strings.NewReplacer(
+	colorNormal, "#[default]",
+	colorGood, "#[fg=green]",
+	colorWarning, "#[fg=yellow]",
+	colorUrgent, "#[fg=red]",
+)
+
+// plainText removes the statuscolors escapes and other control characters
+func plainText(status string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, status)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// peripheralSigns are shown for the UPower device kinds, others get the
+// battery sign
+var peripheralSigns = map[string]string{
+	"mouse":        "",
+	"keyboard":     "",
+	"gaming-input": "",
+	"headset":      "",
+	"headphones":   "",
+}
+
+// peripheralsConfig sets when a peripheral's battery is shown as low
+type peripheralsConfig struct {
+	// Warning is the percentage below which a battery is highlighted
+	Warning int `json:"warning"`
+}
+
+// updatePeripherals shows the battery levels of wireless mice, keyboards,
+// gamepads and headsets known to UPower, highlighting low ones. The laptop
+// battery is left to the power module. It hides itself if there are none.
+func updatePeripherals() string {
+	var out, err = timedCommand("upower", "--enumerate").Output()
+	if err != nil {
+		if _, missing := exec.LookPath("upower"); missing != nil {
+			return ""
+		}
+		return batterySign50 + " ERR"
+	}
+
+	var fields []string
+	for _, path := range strings.Fields(string(out)) {
+		var kind, percentage, ok = upowerPeripheral(path)
+		if !ok {
+			continue
+		}
+		var sign, known = peripheralSigns[kind]
+		if !known {
+			sign = batterySign50
+		}
+		var text = fmt.Sprintf("%s %d%%", sign, percentage)
+		if percentage < cfg.Peripherals.Warning {
+			text = colorUrgent + text + colorNormal
+		}
+		fields = append(fields, text)
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// upowerPeripheral returns the kind and battery level of a UPower device if
+// it is a present peripheral, not a power supply
+func upowerPeripheral(path string) (string, int, bool) {
+	var out, err = timedCommand("upower", "--show-info", path).Output()
+	if err != nil {
+		return "", 0, false
+	}
+	var kind, percentage, supply, present = "", -1, true, true
+	for _, line := range strings.Split(string(out), "\n") {
+		var fields = strings.Fields(line)
+		switch {
+		case len(fields) == 1 && kind == "" && strings.HasPrefix(line, "  "):
+			kind = fields[0]
+		case len(fields) == 3 && fields[0] == "power" && fields[1] == "supply:":
+			supply = fields[2] == "yes"
+		case len(fields) == 2 && fields[0] == "present:":
+			present = fields[1] == "yes"
+		case len(fields) == 2 && fields[0] == "percentage:":
+			var value, err = strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+			if err == nil {
+				percentage = int(value)
+			}
+		}
+	}
+	return kind, percentage, !supply && present && percentage >= 0 && kind != "line-power"
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	pomodoroWorkSign  = ""
+	pomodoroBreakSign = ""
+)
+
+// pomodoroConfig holds the phase lengths of the pomodoro timer
+type pomodoroConfig struct {
+	Work      duration `json:"work"`
+	Break     duration `json:"break"`
+	LongBreak duration `json:"longbreak"`
+	// Rounds is the number of work phases before a long break
+	Rounds int `json:"rounds"`
+	// Hook is run through sh on every phase change with $POMODORO_PHASE set
+	// to work, break, longbreak or stopped
+	Hook string `json:"hook,omitempty"`
+}
+
+var pomodoro struct {
+	sync.Mutex
+	phase  string        // "" while stopped, "work", "break" or "longbreak"
+	end    time.Time     // when the current phase is over
+	paused time.Duration // remaining time of a paused phase, 0 while running
+	rounds int           // completed work phases
+}
+
+// updatePomodoro shows the current phase and its remaining time, it hides
+// itself while the timer is stopped
+func updatePomodoro() string {
+	pomodoro.Lock()
+	defer pomodoro.Unlock()
+	if pomodoro.phase == "" {
+		return ""
+	}
+
+	var left = pomodoro.paused
+	if left == 0 {
+		if !time.Now().Before(pomodoro.end) {
+			nextPomodoroPhase()
+		}
+		left = time.Until(pomodoro.end)
+	}
+	var sign = pomodoroWorkSign
+	if pomodoro.phase != "work" {
+		sign = pomodoroBreakSign
+	}
+	var text = fmt.Sprintf("%s %s %02d:%02d", sign, pomodoro.phase, int(left.Minutes()), int(left.Seconds())%60)
+	if pomodoro.paused != 0 {
+		text += " paused"
+	}
+	return text
+}
+
+// pomodoroCommand controls the timer: start (or resume), pause (or resume),
+// skip to the next phase and stop
+func pomodoroCommand(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: pomodoro start|pause|skip|stop")
+	}
+
+	pomodoro.Lock()
+	defer refresh("pomodoro")
+	defer pomodoro.Unlock()
+	switch args[0] {
+	case "start":
+		if pomodoro.phase == "" {
+			pomodoro.rounds = 0
+			setPomodoroPhase("work")
+		} else if pomodoro.paused != 0 {
+			pomodoro.end, pomodoro.paused = time.Now().Add(pomodoro.paused), 0
+		}
+	case "pause":
+		if pomodoro.phase == "" {
+			return "", fmt.Errorf("pomodoro not running")
+		} else if pomodoro.paused != 0 {
+			pomodoro.end, pomodoro.paused = time.Now().Add(pomodoro.paused), 0
+		} else {
+			pomodoro.paused = time.Until(pomodoro.end)
+		}
+	case "skip":
+		if pomodoro.phase == "" {
+			return "", fmt.Errorf("pomodoro not running")
+		}
+		nextPomodoroPhase()
+	case "stop":
+		setPomodoroPhase("")
+	default:
+		return "", fmt.Errorf("unknown pomodoro command %q", args[0])
+	}
+	return pomodoro.phase, nil
+}
+
+// nextPomodoroPhase switches from work to a break or from a break to work
+func nextPomodoroPhase() {
+	if pomodoro.phase != "work" {
+		setPomodoroPhase("work")
+		return
+	}
+	pomodoro.rounds++
+	if cfg.Pomodoro.Rounds > 0 && pomodoro.rounds%cfg.Pomodoro.Rounds == 0 {
+		setPomodoroPhase("longbreak")
+	} else {
+		setPomodoroPhase("break")
+	}
+}
+
+// setPomodoroPhase starts the given phase and runs the hook
+func setPomodoroPhase(phase string) {
+	var length = map[string]duration{
+		"work":      cfg.Pomodoro.Work,
+		"break":     cfg.Pomodoro.Break,
+		"longbreak": cfg.Pomodoro.LongBreak,
+	}[phase]
+	pomodoro.phase = phase
+	pomodoro.end = time.Now().Add(length.Duration)
+	pomodoro.paused = 0
+
+	if phase == "" {
+		phase = "stopped"
+	}
+	runHook(cfg.Pomodoro.Hook, "POMODORO_PHASE="+phase)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// ownProcessGroup leaves cmd as it is where there are no process groups
+func ownProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills just cmd where there are no process groups
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
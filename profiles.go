@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// profileConfig changes the bar depending on the power source
+type profileConfig struct {
+	// Hide stops updating these modules and leaves them out of the bar
+	Hide []string `json:"hide,omitempty"`
+	// Slowdown multiplies the update intervals of all modules
+	Slowdown float64 `json:"slowdown,omitempty"`
+}
+
+var profile struct {
+	sync.Mutex
+	name    string        // "ac" or "battery", set by the power module
+	changed chan struct{} // closed when the profile changes
+}
+
+// setPowerProfile switches to the profile of the power source. All modules
+// are updated right away so hidden ones disappear and intervals change.
+func setPowerProfile(name string) {
+	profile.Lock()
+	defer profile.Unlock()
+	if profile.name != name {
+		profile.name = name
+		if profile.changed != nil {
+			close(profile.changed)
+		}
+		profile.changed = make(chan struct{})
+	}
+}
+
+// profileChanges returns a channel that is closed on the next profile change
+func profileChanges() <-chan struct{} {
+	profile.Lock()
+	defer profile.Unlock()
+	if profile.changed == nil {
+		profile.changed = make(chan struct{})
+	}
+	return profile.changed
+}
+
+// activeProfile returns the settings of the current power source
+func activeProfile() profileConfig {
+	profile.Lock()
+	defer profile.Unlock()
+	return cfg.Profiles[profile.name]
+}
+
+// profileHides reports whether the current profile hides the named module
+func profileHides(name string) bool {
+	for _, hidden := range activeProfile().Hide {
+		if hidden == name {
+			return true
+		}
+	}
+	return false
+}
+
+// profileInterval scales a module's interval by the current profile
+func profileInterval(interval time.Duration) time.Duration {
+	if slowdown := activeProfile().Slowdown; slowdown > 0 {
+		return time.Duration(float64(interval) * slowdown)
+	}
+	return interval
+}
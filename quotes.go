@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	quotesSign = ""
+)
+
+// quotesConfig is the watchlist of stocks and currency pairs
+type quotesConfig struct {
+	// Provider is one of the quoteProviders
+	Provider string `json:"provider"`
+	// Symbols like "AAPL" or "EURUSD=X" for yahoo and "EUR/USD" for
+	// frankfurter
+	Symbols []string `json:"symbols,omitempty"`
+	// Rotate shows the next symbol after this long
+	Rotate  duration `json:"rotate"`
+	Refresh duration `json:"refresh"`
+}
+
+// quoteProvider fetches the current price and the change since the previous
+// close in percent
+type quoteProvider interface {
+	quote(symbol string) (quote, error)
+}
+
+// quoteProviders can be selected in the config
+var quoteProviders = map[string]quoteProvider{
+	"yahoo":       yahooFinance{},
+	"frankfurter": frankfurter{},
+}
+
+var quotes struct {
+	last map[string]quote
+	poll poller
+}
+
+// updateQuotes shows one symbol of the watchlist after another in a field
+// padded to the width of the longest one, so the bar does not jump around
+func updateQuotes() string {
+	if len(cfg.Quotes.Symbols) == 0 {
+		return ""
+	}
+	var provider, ok = quoteProviders[cfg.Quotes.Provider]
+	if !ok {
+		return quotesSign + " ERR"
+	}
+	quotes.poll.refresh = cfg.Quotes.Refresh.Duration
+	if quotes.poll.due() {
+		var last = map[string]quote{}
+		var err error
+		for _, symbol := range cfg.Quotes.Symbols {
+			var q quote
+			if q, err = provider.quote(symbol); err != nil {
+				break
+			}
+			last[symbol] = q
+		}
+		if err == nil {
+			quotes.last = last
+		}
+		quotes.poll.done(err)
+	}
+	if len(quotes.last) == 0 {
+		return quotesSign + " ERR"
+	}
+
+	var symbols []string
+	var width = 0
+	for symbol, q := range quotes.last {
+		symbols = append(symbols, symbol)
+		if w := utf8.RuneCountInString(formatQuote(symbol, q)); w > width {
+			width = w
+		}
+	}
+	sort.Strings(symbols)
+	var rotate = int64(cfg.Quotes.Rotate.Seconds())
+	if rotate < 1 {
+		rotate = 1
+	}
+	var symbol = symbols[time.Now().Unix()/rotate%int64(len(symbols))]
+	var q = quotes.last[symbol]
+	var padding = strings.Repeat(" ", width-utf8.RuneCountInString(formatQuote(symbol, q)))
+	return fmt.Sprintf("%s %s %s %s%s", quotesSign, symbol, formatPrice(q.price, ""), formatChange(q.change), padding)
+}
+
+// formatQuote renders a quote without colors like "AAPL 189.20 +1.2%"
+func formatQuote(symbol string, q quote) string {
+	return fmt.Sprintf("%s %s %+.1f%%", symbol, formatPrice(q.price, ""), q.change)
+}
+
+type yahooFinance struct{}
+
+func (yahooFinance) quote(symbol string) (quote, error) {
+	var data struct {
+		Chart struct {
+			Result []struct {
+				Meta struct {
+					RegularMarketPrice float64
+					ChartPreviousClose float64
+				}
+			}
+		}
+	}
+	if err := getJSON(weatherClient, "https://query1.finance.yahoo.com/v8/finance/chart/"+url.PathEscape(symbol), &data); err != nil {
+		return quote{}, err
+	}
+	if len(data.Chart.Result) == 0 || data.Chart.Result[0].Meta.ChartPreviousClose == 0 {
+		return quote{}, fmt.Errorf("yahoo: no quote for %s", symbol)
+	}
+	var meta = data.Chart.Result[0].Meta
+	return quote{meta.RegularMarketPrice, (meta.RegularMarketPrice/meta.ChartPreviousClose - 1) * 100}, nil
+}
+
+type frankfurter struct{}
+
+func (frankfurter) quote(symbol string) (quote, error) {
+	var pair = strings.Split(strings.ToUpper(symbol), "/")
+	if len(pair) != 2 {
+		return quote{}, fmt.Errorf("frankfurter: invalid pair %q, use e.g. EUR/USD", symbol)
+	}
+	var data struct {
+		Rates map[string]map[string]float64
+	}
+	var since = time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	if err := getJSON(weatherClient, fmt.Sprintf("https://api.frankfurter.app/%s..?from=%s&to=%s",
+		since, pair[0], pair[1]), &data); err != nil {
+		return quote{}, err
+	}
+
+	// the rates are published on work days only
+	var days []string
+	for day := range data.Rates {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	if len(days) < 2 {
+		return quote{}, fmt.Errorf("frankfurter: no rates for %s", symbol)
+	}
+	var now, before = data.Rates[days[len(days)-1]][pair[1]], data.Rates[days[len(days)-2]][pair[1]]
+	if before == 0 {
+		return quote{}, fmt.Errorf("frankfurter: no rates for %s", symbol)
+	}
+	return quote{now, (now/before - 1) * 100}, nil
+}
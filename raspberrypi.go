@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	piSign         = ""
+	underVoltsSign = "⚡"
+
+	// bits of vcgencmd get_throttled, the same bits shifted by 16 tell
+	// whether it happened since boot
+	piUnderVoltage = 1 << 0
+	piFreqCapped   = 1 << 1
+	piThrottled    = 1 << 2
+	piSoftTemp     = 1 << 3
+	piSinceBoot    = 16
+)
+
+// updatePi shows the SoC temperature of a Raspberry Pi and warns about under
+// voltage and throttling, which a weak power supply causes. Problems that are
+// over by now are marked with a !. It hides itself on other machines.
+func updatePi() string {
+	var temp, err = piTemp()
+	if err != nil {
+		return ""
+	}
+	record("soctemp", temp)
+	var text = fmt.Sprintf("%s %d°C", piSign, int(temp))
+
+	flags, err := piThrottledFlags()
+	switch {
+	case err != nil:
+		return text
+	case flags&piUnderVoltage != 0:
+		return fmt.Sprintf("%s%s %s%s", colorUrgent, text, underVoltsSign, colorNormal)
+	case flags&(piFreqCapped|piThrottled|piSoftTemp) != 0:
+		return fmt.Sprintf("%s%s throttled%s", colorWarning, text, colorNormal)
+	case flags>>piSinceBoot != 0:
+		return text + " !"
+	}
+	return text
+}
+
+// piTemp returns the SoC temperature in °C from its thermal zone, or from
+// vcgencmd where that isn't available
+func piTemp() (float64, error) {
+	if millis, err := readProcInt(sysPath("/sys/class/thermal/thermal_zone0/temp")); err == nil && isPi() {
+		return float64(millis) / 1000, nil
+	}
+	// temp=45.6'C
+	var out, err = timedCommand("vcgencmd", "measure_temp").Output()
+	if err != nil {
+		return 0, err
+	}
+	var temp float64
+	_, err = fmt.Sscanf(strings.TrimPrefix(string(out), "temp="), "%f", &temp)
+	return temp, err
+}
+
+// piThrottledFlags parses the output of vcgencmd get_throttled, like
+// throttled=0x50005
+func piThrottledFlags() (int64, error) {
+	var out, err = timedCommand("vcgencmd", "get_throttled").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(string(out)), "throttled=0x"), 16, 64)
+}
+
+// isPi checks the device tree model, so the thermal zone of other machines
+// isn't mistaken for a Pi's SoC
+func isPi() bool {
+	var model, err = ioutil.ReadFile(procPath("/proc/device-tree/model"))
+	return err == nil && strings.HasPrefix(string(model), "Raspberry Pi")
+}
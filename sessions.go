@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sshSign      = ""
+	sessionsSign = ""
+)
+
+// sessionSources are tried in this order for the login sessions
+var sessionSources = []string{"logind", "utmp"}
+
+// sessionFallback remembers the first of sessionSources that works
+var sessionFallback fallback
+
+// sshConfig sets what the ssh module shows
+type sshConfig struct {
+	// Host adds where the latest ssh session comes from
+	Host bool `json:"host"`
+}
+
+// updateSSH shows the number of sessions logged in through ssh and, if
+// configured, where the latest one comes from. It hides itself if there are
+// none.
+func updateSSH() string {
+	var sessions, err = loginSessions()
+	if err != nil {
+		return sshSign + " ERR"
+	}
+	var count = 0
+	var latest session
+	for _, s := range sessions {
+		if s.ssh {
+			if count == 0 || s.order > latest.order {
+				latest = s
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	} else if cfg.SSH.Host && latest.host != "" {
+		return fmt.Sprintf("%s%s %d %s%s", colorWarning, sshSign, count, latest.host, colorNormal)
+	}
+	return fmt.Sprintf("%s%s %d%s", colorWarning, sshSign, count, colorNormal)
+}
+
+// session is a login of a user
+type session struct {
+	remote bool   // logged in over the network
+	ssh    bool   // logged in through sshd
+	host   string // where a remote session comes from
+	order  int64  // sorts the sessions by start, later ones are larger
+}
+
+// updateSessions shows the number of login sessions, remote ones are counted
+// separately in the warning color so unexpected logins stand out
+func updateSessions() string {
+	var sessions, err = loginSessions()
+	if err != nil {
+		return sessionsSign + " ERR"
+	}
+	var remote = 0
+	for _, s := range sessions {
+		if s.remote {
+			remote++
+		}
+	}
+	if remote > 0 {
+		return fmt.Sprintf("%s %d %s%d remote%s", sessionsSign, len(sessions), colorWarning, remote, colorNormal)
+	}
+	return fmt.Sprintf("%s %d", sessionsSign, len(sessions))
+}
+
+// loginSessions lists the sessions from the first of sessionSources that works
+func loginSessions() (sessions []session, err error) {
+	err = sessionFallback.try(sessionSources, func(source string) error {
+		var err error
+		if source == "logind" {
+			sessions, err = logindSessions()
+		} else {
+			sessions, err = utmpSessions()
+		}
+		return err
+	})
+	return sessions, err
+}
+
+// logindSessions lists the user sessions logind knows about, leaving out
+// display managers and sessions that are closing
+func logindSessions() ([]session, error) {
+	var out, err = output(nil, "loginctl", "list-sessions", "--no-legend", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+	var args = []string{"show-session", "--property=Class", "--property=State", "--property=Remote",
+		"--property=RemoteHost", "--property=Service", "--property=TimestampMonotonic"}
+	var ids = 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			args = append(args, fields[0])
+			ids++
+		}
+	}
+	if ids == 0 {
+		return nil, nil
+	}
+	if out, err = output(nil, "loginctl", args...); err != nil {
+		return nil, err
+	}
+
+	var sessions []session
+	// the properties of each session are separated by an empty line
+	for _, block := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		var props = map[string]string{}
+		for _, line := range strings.Split(block, "\n") {
+			if i := strings.IndexByte(line, '='); i > 0 {
+				props[line[:i]] = line[i+1:]
+			}
+		}
+		if props["Class"] != "user" || props["State"] == "closing" {
+			continue
+		}
+		var started, _ = strconv.ParseInt(props["TimestampMonotonic"], 10, 64)
+		sessions = append(sessions, session{
+			remote: props["Remote"] == "yes",
+			ssh:    props["Service"] == "sshd",
+			host:   props["RemoteHost"],
+			order:  started,
+		})
+	}
+	return sessions, nil
+}
+
+// utmpSessions lists the logins known to who(1). Remote ones have the host
+// they come from in parentheses, local ones the X display or nothing. who
+// can't tell how someone logged in, so all remote sessions count as ssh.
+func utmpSessions() ([]session, error) {
+	var out, err = output(nil, "who")
+	if err != nil {
+		return nil, err
+	}
+	var sessions []session
+	for i, line := range strings.Split(string(out), "\n") {
+		var fields = strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var s = session{order: int64(i)}
+		// GNU who prints ISO dates, others e.g. "Oct 15 10:00"
+		if len(fields) >= 4 {
+			if t, err := time.ParseInLocation("2006-01-02 15:04", fields[2]+" "+fields[3], time.Local); err == nil {
+				s.order = t.Unix()
+			}
+		}
+		var open, end = strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+		if open >= 0 && end > open {
+			var host = line[open+1 : end]
+			if host != "" && !strings.HasPrefix(host, ":") && !strings.HasPrefix(host, "tmux(") {
+				s.remote, s.ssh, s.host = true, true, host
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
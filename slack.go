@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	slackSign = ""
+)
+
+// slackConfig lists the workspaces whose mentions are counted
+type slackConfig struct {
+	Workspaces []slackWorkspace `json:"workspaces,omitempty"`
+	Refresh    duration         `json:"refresh"`
+}
+
+// slackWorkspace is reached with a user token (xoxp-…) of the workspace
+type slackWorkspace struct {
+	// Name is shown in front of the workspace's count
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty" secret:"true"`
+	// TokenCommand prints the token instead, e.g. "pass slack/work"
+	TokenCommand string `json:"tokencommand,omitempty"`
+}
+
+// slackState is the last known mention count of a workspace, it is kept
+// while the workspace is backed off
+type slackState struct {
+	mentions int
+	fetched  bool
+	poll     poller
+}
+
+var slack = map[string]*slackState{}
+
+// updateSlack shows the mentions and direct messages per workspace and hides
+// itself if there are none. Every workspace is polled on its own, failing or
+// rate limited ones back off without affecting the others.
+func updateSlack() string {
+	var fields []string
+	for _, workspace := range cfg.Slack.Workspaces {
+		var state, ok = slack[workspace.Name]
+		if !ok {
+			state = &slackState{poll: poller{refresh: cfg.Slack.Refresh.Duration}}
+			slack[workspace.Name] = state
+		}
+		if state.poll.due() {
+			var mentions, retry, err = fetchSlack(workspace)
+			state.poll.done(err)
+			if retry > 0 {
+				state.poll.next = time.Now().Add(retry)
+			}
+			if err == nil {
+				state.mentions, state.fetched = mentions, true
+			}
+		}
+
+		switch {
+		case !state.fetched:
+			fields = append(fields, workspace.Name+" ERR")
+		case state.mentions > 0:
+			fields = append(fields, fmt.Sprintf("%s %d", workspace.Name, state.mentions))
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return slackSign + " " + strings.Join(fields, " ")
+}
+
+// fetchSlack sums the mention counts of all conversations of a workspace. If
+// Slack asks to slow down, it returns how long to wait.
+func fetchSlack(workspace slackWorkspace) (int, time.Duration, error) {
+	var token = workspace.Token
+	if workspace.TokenCommand != "" {
+		var err error
+		if token, err = secret(workspace.TokenCommand); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// client.counts is what the Slack clients use for their badges
+	var req, _ = http.NewRequest("POST", "https://slack.com/api/client.counts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	var resp, err = weatherClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var secs, _ = strconv.Atoi(resp.Header.Get("Retry-After"))
+		return 0, time.Duration(secs) * time.Second, fmt.Errorf("slack: %s", resp.Status)
+	}
+
+	type counts []struct {
+		Mentions int `json:"mention_count"`
+	}
+	var result struct {
+		OK       bool
+		Error    string
+		Channels counts
+		MPIMs    counts
+		IMs      counts
+	}
+	if err = decodeJSON(resp, &result); err != nil {
+		return 0, 0, err
+	} else if !result.OK {
+		return 0, 0, fmt.Errorf("slack: %s", result.Error)
+	}
+	var mentions = 0
+	for _, list := range []counts{result.Channels, result.MPIMs, result.IMs} {
+		for _, conversation := range list {
+			mentions += conversation.Mentions
+		}
+	}
+	return mentions, 0, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsdPacket keeps the packets below the usual MTU
+const statsdPacket = 1400
+
+// statsdConfig enables sending the metrics to a StatsD server
+type statsdConfig struct {
+	// Address is host:port of the server, e.g. "localhost:8125"
+	Address string `json:"address,omitempty"`
+	// Prefix is put in front of every metric name, e.g. "laptop."
+	Prefix   string   `json:"prefix,omitempty"`
+	Interval duration `json:"interval"`
+	// Metrics limits the metrics sent, all are sent by default
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+// statsdName turns a metric name like "disk:/home" into a valid StatsD name
+var statsdName = strings.NewReplacer(":", ".", "/", "_", "|", "_", "@", "_")
+
+// sendStatsD sends the metrics as gauges over UDP every interval
+func sendStatsD() {
+	if cfg.StatsD.Address == "" {
+		return
+	}
+	for range time.Tick(cfg.StatsD.Interval.Duration) {
+		// dialing UDP only resolves the address, which may change
+		var conn, err = net.Dial("udp", cfg.StatsD.Address)
+		if err != nil {
+			continue
+		}
+		var names, values = snapshot(cfg.StatsD.Metrics)
+		var packet []byte
+		for _, name := range names {
+			var line = cfg.StatsD.Prefix + statsdName.Replace(name) + ":" +
+				strconv.FormatFloat(values[name], 'f', -1, 64) + "|g\n"
+			if len(packet)+len(line) > statsdPacket {
+				conn.Write(packet)
+				packet = nil
+			}
+			packet = append(packet, line...)
+		}
+		if len(packet) > 0 {
+			conn.Write(packet)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	failedUnitsSign = ""
+	journalSign     = ""
+
+	journalWindow = 10 * time.Minute // errors older than this are not counted
+)
+
+// journal holds the timestamps of recent err priority journal entries
+var journal struct {
+	sync.Mutex
+	following bool
+	errors    []time.Time
+	reset     time.Time // errors before this have been acknowledged
+}
+
+// updateFailedUnits shows the number of failed system and user units in the
+// urgent color and hides itself while everything is running fine
+func updateFailedUnits() string {
+	var system, err = countFailedUnits()
+	var user, userErr = countFailedUnits("--user")
+	if err != nil && userErr != nil {
+		return failedUnitsSign + " ERR"
+	}
+	if system+user == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s%s %d failed%s", colorUrgent, failedUnitsSign, system+user, colorNormal)
+}
+
+// countFailedUnits lists the failed units of the system or, given --user, the
+// user's service manager
+func countFailedUnits(args ...string) (int, error) {
+	args = append(args, "--failed", "--plain", "--no-legend")
+	var out, err = output(nil, "systemctl", args...)
+	if err != nil {
+		return 0, err
+	}
+	return countLines(out, func(line string) bool { return true }), nil
+}
+
+// updateJournal shows the number of errors logged to the journal within the
+// last journalWindow, it hides itself when there were none
+func updateJournal() string {
+	journal.Lock()
+	defer journal.Unlock()
+	if !journal.following {
+		return journalSign + " ERR"
+	}
+
+	var now = time.Now()
+	var recent = journal.errors[:0]
+	for _, stamp := range journal.errors {
+		if now.Sub(stamp) < journalWindow {
+			recent = append(recent, stamp)
+		}
+	}
+	journal.errors = recent
+
+	var count = 0
+	for _, stamp := range journal.errors {
+		if stamp.After(journal.reset) {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s%s %d%s", colorWarning, journalSign, count, colorNormal)
+}
+
+// resetJournal acknowledges the errors seen so far
+func resetJournal() {
+	journal.Lock()
+	journal.reset = time.Now()
+	journal.Unlock()
+}
+
+// journalCommand acknowledges the errors, e.g. when clicking the module
+func journalCommand(args []string) (string, error) {
+	if len(args) != 1 || args[0] != "reset" {
+		return "", fmt.Errorf("usage: journal reset")
+	}
+	resetJournal()
+	refresh("journal")
+	return "", nil
+}
+
+// followJournal tails the journal at err priority and records when errors
+// were logged. journalctl is restarted should it ever exit.
+func followJournal() {
+	for {
+		var cmd = exec.Command("journalctl", "--follow", "--priority=err", "--output=short-unix",
+			fmt.Sprintf("--since=-%dmin", int(journalWindow.Minutes())))
+		var out, err = cmd.StdoutPipe()
+		if err == nil && cmd.Start() == nil {
+			// journalctl starts by repeating everything within the window
+			journal.Lock()
+			journal.following = true
+			journal.errors = nil
+			journal.Unlock()
+
+			for scanner := bufio.NewScanner(out); scanner.Scan(); {
+				var stamp float64
+				if _, err := fmt.Sscanf(scanner.Text(), "%f", &stamp); err != nil {
+					continue // e.g. "-- No entries --"
+				}
+				journal.Lock()
+				journal.errors = append(journal.errors, time.Unix(0, int64(stamp*1e9)))
+				journal.Unlock()
+			}
+			cmd.Wait()
+		}
+
+		journal.Lock()
+		journal.following = false
+		journal.Unlock()
+		time.Sleep(time.Minute)
+	}
+}
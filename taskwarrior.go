@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	taskSign = ""
+)
+
+// taskConfig selects the taskwarrior tasks counted by the tasks module
+type taskConfig struct {
+	// Filter is passed to task export, by default the pending tasks due
+	// before tomorrow
+	Filter []string `json:"filter"`
+	// Urgency colors the field once a task reaches this urgency
+	Urgency float64 `json:"urgency"`
+}
+
+// taskTimeLayout is how taskwarrior exports dates
+const taskTimeLayout = "20060102T150405Z"
+
+// updateTasks shows the number of taskwarrior tasks due today and how many of
+// them are overdue. It hides itself if nothing is due.
+func updateTasks() string {
+	var args = append([]string{"rc.verbose=nothing", "rc.hooks=off"}, cfg.Task.Filter...)
+	var out, err = timedCommand("task", append(args, "export")...).Output()
+	if err != nil {
+		return taskSign + " ERR"
+	}
+	var tasks []struct {
+		Due     string
+		Urgency float64
+	}
+	if err = json.Unmarshal(out, &tasks); err != nil {
+		return taskSign + " ERR"
+	}
+	if len(tasks) == 0 {
+		return ""
+	}
+
+	var overdue, urgent = 0, false
+	for _, task := range tasks {
+		if due, err := time.Parse(taskTimeLayout, task.Due); err == nil && due.Before(time.Now()) {
+			overdue++
+		}
+		urgent = urgent || task.Urgency >= cfg.Task.Urgency
+	}
+	var text = fmt.Sprintf("%s %d", taskSign, len(tasks))
+	if overdue > 0 {
+		return fmt.Sprintf("%s%s %d overdue%s", colorUrgent, text, overdue, colorNormal)
+	} else if urgent {
+		return colorWarning + text + colorNormal
+	}
+	return text
+}
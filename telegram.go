@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	telegramSign = ""
+
+	telegramPollTimeout = 30 * time.Second
+)
+
+// telegramConfig is the bot whose incoming messages are counted. Bots only
+// see their own chats and the groups they were added to, so the bot has to be
+// a member of the chats that should be counted.
+type telegramConfig struct {
+	Token string `json:"token,omitempty" secret:"true"`
+	// TokenCommand prints the bot token instead
+	TokenCommand string `json:"tokencommand,omitempty"`
+	// Chats limits the count to these chat ids, @usernames or titles
+	Chats []string `json:"chats,omitempty"`
+}
+
+var telegram struct {
+	sync.Mutex
+	unread map[int64]int
+	err    error
+}
+
+var telegramClient = &http.Client{Timeout: telegramPollTimeout + 30*time.Second}
+
+// updateTelegram shows the number of chats with unread messages and the
+// number of messages in them. It hides itself if there are none.
+func updateTelegram() string {
+	telegram.Lock()
+	defer telegram.Unlock()
+	if telegram.err != nil {
+		return telegramSign + " ERR"
+	}
+	var messages = 0
+	for _, count := range telegram.unread {
+		messages += count
+	}
+	if messages == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d/%d", telegramSign, len(telegram.unread), messages)
+}
+
+// telegramCommand marks all messages as read, e.g. after reading them on the
+// phone
+func telegramCommand(args []string) (string, error) {
+	if len(args) != 1 || args[0] != "read" {
+		return "", fmt.Errorf("usage: telegram read")
+	}
+	telegram.Lock()
+	telegram.unread = map[int64]int{}
+	telegram.Unlock()
+	refresh("telegram")
+	return "", nil
+}
+
+// watchTelegram long-polls the bot API for new messages and retries with
+// exponential backoff after errors
+func watchTelegram() {
+	if cfg.Telegram.Token == "" && cfg.Telegram.TokenCommand == "" {
+		return
+	}
+	telegram.unread = map[int64]int{}
+	var poll = poller{}
+	var offset int64
+	for {
+		var next, err = pollTelegram(offset)
+		telegram.Lock()
+		telegram.err = err
+		telegram.Unlock()
+		refresh("telegram")
+
+		poll.done(err)
+		if err == nil {
+			offset = next
+		} else {
+			time.Sleep(time.Until(poll.next))
+		}
+	}
+}
+
+// pollTelegram waits for the updates after offset and counts the messages of
+// the configured chats. It returns the offset of the next poll.
+func pollTelegram(offset int64) (int64, error) {
+	var token = cfg.Telegram.Token
+	if cfg.Telegram.TokenCommand != "" {
+		var err error
+		if token, err = secret(cfg.Telegram.TokenCommand); err != nil {
+			return offset, err
+		}
+	}
+
+	var query = url.Values{
+		"offset":          {strconv.FormatInt(offset, 10)},
+		"timeout":         {strconv.Itoa(int(telegramPollTimeout.Seconds()))},
+		"allowed_updates": {`["message"]`},
+	}
+	var resp, err = telegramClient.Get("https://api.telegram.org/bot" + token + "/getUpdates?" + query.Encode())
+	if err != nil {
+		// the error contains the URL and so the token
+		return offset, fmt.Errorf("telegram: request failed")
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK          bool
+		Description string
+		Result      []struct {
+			UpdateID int64 `json:"update_id"`
+			Message  *struct {
+				Chat struct {
+					ID       int64
+					Username string
+					Title    string
+				}
+			}
+		}
+	}
+	if err = decodeJSON(resp, &result); err != nil {
+		return offset, err
+	} else if !result.OK {
+		return offset, fmt.Errorf("telegram: %s", result.Description)
+	}
+
+	telegram.Lock()
+	defer telegram.Unlock()
+	for _, update := range result.Result {
+		offset = update.UpdateID + 1
+		if update.Message == nil {
+			continue
+		}
+		var chat = update.Message.Chat
+		if telegramChatWanted(chat.ID, chat.Username, chat.Title) {
+			telegram.unread[chat.ID]++
+		}
+	}
+	return offset, nil
+}
+
+// telegramChatWanted reports whether a chat is one of the configured ones,
+// all chats are counted if none are configured
+func telegramChatWanted(id int64, username, title string) bool {
+	if len(cfg.Telegram.Chats) == 0 {
+		return true
+	}
+	for _, chat := range cfg.Telegram.Chats {
+		if chat == strconv.FormatInt(id, 10) || chat == title ||
+			(username != "" && strings.TrimPrefix(chat, "@") == username) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	tickerSign = ""
+
+	tickerMinRefresh = time.Minute // the ticker never polls more often
+)
+
+// tickerConfig lists the coins shown by the crypto ticker
+type tickerConfig struct {
+	// Coins are CoinGecko coin ids like "bitcoin"
+	Coins []string `json:"coins,omitempty"`
+	// Currency the prices are shown in, e.g. "usd" or "eur"
+	Currency string `json:"currency"`
+	// API is the base URL of a CoinGecko compatible API
+	API     string   `json:"api"`
+	Refresh duration `json:"refresh"`
+}
+
+// coinSymbols abbreviates the most common coins
+var coinSymbols = map[string]string{
+	"bitcoin": "BTC", "ethereum": "ETH", "monero": "XMR", "litecoin": "LTC",
+	"solana": "SOL", "cardano": "ADA", "dogecoin": "DOGE", "ripple": "XRP",
+}
+
+var currencySigns = map[string]string{"usd": "$", "eur": "€", "gbp": "£", "jpy": "¥"}
+
+// quote is a price and its recent change in percent
+type quote struct {
+	price, change float64
+}
+
+var ticker struct {
+	quotes map[string]quote
+	poll   poller
+}
+
+// updateTicker shows the price of every configured coin, colored by its
+// change within the last 24 hours. Prices are fetched at most once per
+// refresh interval and kept while the API is unreachable.
+func updateTicker() string {
+	if len(cfg.Ticker.Coins) == 0 {
+		return ""
+	}
+	ticker.poll.refresh = cfg.Ticker.Refresh.Duration
+	if ticker.poll.refresh < tickerMinRefresh {
+		ticker.poll.refresh = tickerMinRefresh
+	}
+	if ticker.poll.due() {
+		var quotes, err = fetchCoinQuotes()
+		if err == nil {
+			ticker.quotes = quotes
+		}
+		ticker.poll.done(err)
+	}
+	if ticker.quotes == nil {
+		return tickerSign + " ERR"
+	}
+
+	var fields = []string{tickerSign}
+	for _, coin := range cfg.Ticker.Coins {
+		var quote, ok = ticker.quotes[coin]
+		if !ok {
+			continue
+		}
+		var symbol = coinSymbols[coin]
+		if symbol == "" {
+			symbol = strings.ToUpper(coin)
+		}
+		fields = append(fields, fmt.Sprintf("%s %s %s", symbol, formatPrice(quote.price, cfg.Ticker.Currency),
+			formatChange(quote.change)))
+	}
+	return strings.Join(fields, " ")
+}
+
+// fetchCoinQuotes asks the API for the prices of all configured coins
+func fetchCoinQuotes() (map[string]quote, error) {
+	var currency = strings.ToLower(cfg.Ticker.Currency)
+	var query = url.Values{
+		"ids":                 {strings.Join(cfg.Ticker.Coins, ",")},
+		"vs_currencies":       {currency},
+		"include_24hr_change": {"true"},
+	}
+	var data map[string]map[string]float64
+	if err := getJSON(weatherClient, strings.TrimSuffix(cfg.Ticker.API, "/")+"/simple/price?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	var prices = map[string]quote{}
+	for coin, values := range data {
+		prices[coin] = quote{values[currency], values[currency+"_24h_change"]}
+	}
+	return prices, nil
+}
+
+// formatPrice formats a price with as many decimals as make sense for its
+// magnitude
+func formatPrice(price float64, currency string) string {
+	var text string
+	switch {
+	case price >= 1000:
+		text = fmt.Sprintf("%.0f", price)
+	case price >= 1:
+		text = fmt.Sprintf("%.2f", price)
+	default:
+		text = fmt.Sprintf("%.4f", price)
+	}
+	if sign, ok := currencySigns[strings.ToLower(currency)]; ok {
+		return sign + text
+	}
+	return text + strings.ToUpper(currency)
+}
+
+// formatChange formats a percent change, colored green when rising and red
+// when falling
+func formatChange(change float64) string {
+	var text = fmt.Sprintf("%+.1f%%", change)
+	if change < 0 {
+		return colorUrgent + text + colorNormal
+	}
+	return colorGood + text + colorNormal
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	timerSign     = ""
+	stopwatchSign = ""
+)
+
+// timerConfig configures what happens when a countdown expires
+type timerConfig struct {
+	// Hook is run through sh on expiry with $TIMER_NAME set
+	Hook string `json:"hook,omitempty"`
+}
+
+// timer is a countdown, or a stopwatch if it has no end
+type timer struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+var timers struct {
+	sync.Mutex
+	list []timer
+}
+
+// updateTimers shows the remaining time of all countdowns and the elapsed
+// time of all stopwatches. Expired countdowns run the hook and disappear.
+func updateTimers() string {
+	timers.Lock()
+	defer timers.Unlock()
+
+	var now = time.Now()
+	var fields []string
+	var running = timers.list[:0]
+	for _, t := range timers.list {
+		if t.end.IsZero() {
+			fields = append(fields, fmt.Sprintf("%s %s%s", stopwatchSign, t.label(), clockDuration(now.Sub(t.start))))
+		} else if now.Before(t.end) {
+			fields = append(fields, fmt.Sprintf("%s %s%s", timerSign, t.label(), clockDuration(t.end.Sub(now))))
+		} else {
+			runHook(cfg.Timer.Hook, "TIMER_NAME="+t.name)
+			continue
+		}
+		running = append(running, t)
+	}
+	timers.list = running
+	return strings.Join(fields, fieldSeparator)
+}
+
+// label returns the timer's name followed by a space, if it has one
+func (t timer) label() string {
+	if t.name == "" {
+		return ""
+	}
+	return t.name + " "
+}
+
+// clockDuration formats d as m:ss, or h:mm:ss from an hour on
+func clockDuration(d time.Duration) string {
+	var s = int(d.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// timerCommand starts a countdown (timer 25m tea), a stopwatch (timer
+// stopwatch lap) or stops the timers with the given name or all of them
+// (timer stop [tea])
+func timerCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: timer <duration>|stopwatch|stop [name]")
+	}
+	var name = strings.Join(args[1:], " ")
+
+	timers.Lock()
+	defer refresh("timer")
+	defer timers.Unlock()
+	switch args[0] {
+	case "stopwatch":
+		timers.list = append(timers.list, timer{name: name, start: time.Now()})
+	case "stop":
+		var kept = timers.list[:0]
+		for _, t := range timers.list {
+			if name != "" && t.name != name {
+				kept = append(kept, t)
+			}
+		}
+		timers.list = kept
+	default:
+		var length, err = time.ParseDuration(args[0])
+		if err != nil || length <= 0 {
+			return "", fmt.Errorf("invalid duration %q", args[0])
+		}
+		var now = time.Now()
+		timers.list = append(timers.list, timer{name: name, start: now, end: now.Add(length)})
+	}
+	return fmt.Sprintf("%d timers running", len(timers.list)), nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	timeSyncSign = ""
+
+	timeDriftMax = 100 * time.Millisecond // larger offsets are shown as warning
+)
+
+// timeSync is the state reported by one of the time sync daemons
+type timeSync struct {
+	synced    bool
+	offset    time.Duration
+	hasOffset bool
+}
+
+// timeSyncBackends are asked in order, the first one running is used
+var timeSyncBackends = []func() (timeSync, error){
+	chronySync,
+	ntpdSync,
+	timesyncdSync,
+}
+
+// updateTimeSync shows whether the clock is synchronized and its offset to the
+// time servers, large offsets and unsynchronized clocks are colored
+func updateTimeSync() string {
+	for _, backend := range timeSyncBackends {
+		var state, err = backend()
+		if err != nil {
+			continue
+		}
+
+		if !state.synced {
+			return colorWarning + timeSyncSign + " unsync" + colorNormal
+		} else if !state.hasOffset {
+			return timeSyncSign + " sync"
+		}
+		var text = fmt.Sprintf("%s %+dms", timeSyncSign, state.offset.Milliseconds())
+		if state.offset > timeDriftMax || state.offset < -timeDriftMax {
+			return colorWarning + text + colorNormal
+		}
+		return text
+	}
+	return timeSyncSign + " ERR"
+}
+
+// chronySync parses `chronyc tracking`
+func chronySync() (timeSync, error) {
+	var state timeSync
+	var out, err = timedCommand("chronyc", "tracking").Output()
+	if err != nil {
+		return state, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		var parts = strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var value = strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "Leap status":
+			state.synced = value != "Not synchronised"
+		case "System time":
+			// e.g. "0.000003474 seconds slow of NTP time"
+			var seconds, dir = 0.0, ""
+			if _, err := fmt.Sscanf(value, "%f seconds %s", &seconds, &dir); err != nil {
+				continue
+			}
+			if dir == "slow" {
+				seconds = -seconds
+			}
+			state.offset = time.Duration(seconds * float64(time.Second))
+			state.hasOffset = true
+		}
+	}
+	return state, nil
+}
+
+// ntpdSync reads the leap indicator and offset from ntpd
+func ntpdSync() (timeSync, error) {
+	var state timeSync
+	var out, err = timedCommand("ntpq", "-c", "rv 0 leap,offset").Output()
+	if err != nil {
+		return state, err
+	}
+	// e.g. "leap=00, offset=-0.211", the offset is in milliseconds
+	for _, field := range strings.Split(strings.TrimSpace(string(out)), ",") {
+		var parts = strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "leap":
+			state.synced = parts[1] != "11"
+		case "offset":
+			if ms, err := strconv.ParseFloat(parts[1], 64); err == nil {
+				state.offset = time.Duration(ms * float64(time.Millisecond))
+				state.hasOffset = true
+			}
+		}
+	}
+	return state, nil
+}
+
+// timesyncdSync asks timedatectl, which also reports the offset when
+// systemd-timesyncd is used
+func timesyncdSync() (timeSync, error) {
+	var state timeSync
+	var out, err = timedCommand("timedatectl", "show", "--property=NTPSynchronized", "--value").Output()
+	if err != nil {
+		return state, err
+	}
+	state.synced = strings.TrimSpace(string(out)) == "yes"
+
+	if out, err = timedCommand("timedatectl", "timesync-status").Output(); err != nil {
+		return state, nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		var parts = strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "Offset" {
+			var value = strings.TrimPrefix(strings.TrimSpace(parts[1]), "+")
+			if offset, err := time.ParseDuration(value); err == nil {
+				state.offset, state.hasOffset = offset, true
+			}
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	todoSign = ""
+)
+
+// todoConfig selects the todo.txt tasks counted by the todo module
+type todoConfig struct {
+	File string `json:"file"`
+	// Filter only counts the tasks containing all of these words, like
+	// "@today", "+project" or "(A)"
+	Filter []string `json:"filter,omitempty"`
+}
+
+// updateTodo shows the number of open tasks in the todo.txt file matching
+// the filter and hides itself if there are none
+func updateTodo() string {
+	var content, err = ioutil.ReadFile(expandPath(cfg.Todo.File))
+	if err != nil {
+		return todoSign + " ERR"
+	}
+	var count = countLines(content, func(line string) bool {
+		if strings.HasPrefix(line, "x ") {
+			return false
+		}
+		var words = map[string]bool{}
+		for _, word := range strings.Fields(line) {
+			words[word] = true
+		}
+		for _, word := range cfg.Todo.Filter {
+			if !words[word] {
+				return false
+			}
+		}
+		return true
+	})
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d", todoSign, count)
+}
+
+// watchTodo recounts as soon as the todo.txt file is edited
+func watchTodo() {
+	if enabled("todo") {
+		watch([]string{expandPath(cfg.Todo.File)}, func() { refresh("todo") })
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	torSign = ""
+)
+
+// torConfig sets how the Tor control port is reached
+type torConfig struct {
+	// Control is the control port's address, or the path of its unix socket
+	Control string `json:"control"`
+	// PasswordCommand prints the password set by HashedControlPassword,
+	// otherwise the cookie file is used if Tor offers it
+	PasswordCommand string `json:"passwordcommand,omitempty"`
+}
+
+var (
+	torCookieRx    = regexp.MustCompile(`COOKIEFILE="((?:[^"\\]|\\.)*)"`)
+	torBootstrapRx = regexp.MustCompile(`PROGRESS=(\d+)`)
+)
+
+// updateTor shows its icon once Tor finished bootstrapping and built a
+// circuit, and the bootstrap progress in the warning color until then. Tor
+// not answering on the control port shows as an error.
+func updateTor() string {
+	var progress, established, err = torStatus()
+	switch {
+	case err != nil:
+		return colorUrgent + torSign + " ERR" + colorNormal
+	case progress < 100:
+		return fmt.Sprintf("%s%s %d%%%s", colorWarning, torSign, progress, colorNormal)
+	case !established:
+		return colorWarning + torSign + " no circuit" + colorNormal
+	}
+	return torSign
+}
+
+// torStatus asks Tor for its bootstrap progress and whether it has built a
+// circuit
+func torStatus() (progress int, established bool, err error) {
+	var network = "tcp"
+	if strings.HasPrefix(cfg.Tor.Control, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, cfg.Tor.Control, 5*time.Second)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	var r = bufio.NewReader(conn)
+	var call = func(command string) ([]string, error) {
+		if _, err := fmt.Fprintf(conn, "%s\r\n", command); err != nil {
+			return nil, err
+		}
+		return torReply(r)
+	}
+
+	info, err := call("PROTOCOLINFO 1")
+	if err != nil {
+		return 0, false, err
+	}
+	if err = torAuthenticate(call, strings.Join(info, "\n")); err != nil {
+		return 0, false, err
+	}
+
+	reply, err := call("GETINFO status/bootstrap-phase status/circuit-established")
+	if err != nil {
+		return 0, false, err
+	}
+	for _, line := range reply {
+		if strings.HasPrefix(line, "status/bootstrap-phase=") {
+			if m := torBootstrapRx.FindStringSubmatch(line); m != nil {
+				progress, _ = strconv.Atoi(m[1])
+			}
+		} else if line == "status/circuit-established=1" {
+			established = true
+		}
+	}
+	call("QUIT")
+	return progress, established, nil
+}
+
+// torAuthenticate logs in with the first method PROTOCOLINFO offers that
+// gods can use
+func torAuthenticate(call func(string) ([]string, error), info string) error {
+	var methods = ""
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, "AUTH METHODS=") {
+			methods = "," + strings.Fields(strings.TrimPrefix(line, "AUTH METHODS="))[0] + ","
+		}
+	}
+	var err error
+	switch {
+	case strings.Contains(methods, ",NULL,"):
+		_, err = call("AUTHENTICATE")
+	case strings.Contains(methods, ",HASHEDPASSWORD,") && cfg.Tor.PasswordCommand != "":
+		var password string
+		if password, err = secret(cfg.Tor.PasswordCommand); err == nil {
+			_, err = call("AUTHENTICATE " + strconv.Quote(password))
+		}
+	case strings.Contains(methods, ",COOKIE,"):
+		var m = torCookieRx.FindStringSubmatch(info)
+		if m == nil {
+			return fmt.Errorf("tor: no cookie file")
+		}
+		var path, _ = strconv.Unquote(`"` + m[1] + `"`)
+		var cookie []byte
+		if cookie, err = ioutil.ReadFile(path); err == nil {
+			_, err = call("AUTHENTICATE " + hex.EncodeToString(cookie))
+		}
+	default:
+		err = fmt.Errorf("tor: no usable authentication method in %q", strings.Trim(methods, ","))
+	}
+	return err
+}
+
+// torReply reads the lines of a reply up to the final "250 OK", removing
+// the status codes. Any other status is returned as an error.
+func torReply(r *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		var line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 4 {
+			return nil, fmt.Errorf("tor: malformed reply %q", line)
+		}
+		if line[:3] != "250" {
+			return nil, fmt.Errorf("tor: %s", line)
+		}
+		if line[3] == ' ' {
+			return lines, nil
+		}
+		lines = append(lines, line[4:])
+	}
+}
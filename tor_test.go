@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTorReply(t *testing.T) {
+	var tests = []struct {
+		reply string
+		want  []string
+		fail  bool
+	}{
+		{"250 OK\r\n", nil, false},
+		{"250-version=0.4.8.9\r\n250 OK\r\n", []string{"version=0.4.8.9"}, false},
+		{"250-traffic/read=1024\r\n250-traffic/written=2048\r\n250 OK\r\n",
+			[]string{"traffic/read=1024", "traffic/written=2048"}, false},
+		{"515 Authentication failed: Wrong length on authentication cookie.\r\n", nil, true},
+		{"250-version=0.4.8.9\r\n552 Unrecognized key \"foo\"\r\n", nil, true},
+		{"25\r\n", nil, true},
+		{"250-version=0.4.8.9\r\n", nil, true},
+	}
+	for _, test := range tests {
+		var got, err = torReply(bufio.NewReader(strings.NewReader(test.reply)))
+		if (err != nil) != test.fail || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("torReply(%q) = %q, %v, want %q", test.reply, got, err, test.want)
+		}
+	}
+}
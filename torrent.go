@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	torrentSign = ""
+)
+
+// torrentConfig selects the torrent client whose transfers are shown
+type torrentConfig struct {
+	// Client is "transmission" or "qbittorrent"
+	Client string `json:"client,omitempty"`
+	// URL of the web interface, the client's default port on localhost if
+	// empty
+	URL  string `json:"url,omitempty"`
+	User string `json:"user,omitempty"`
+	// PasswordCommand prints the password of User
+	PasswordCommand string `json:"passwordcommand,omitempty"`
+}
+
+// torrentStats is what the module shows, rates are in bytes per second
+type torrentStats struct {
+	active   int
+	down, up int
+}
+
+// torrentClients asks the named client for its stats, taking the URL to
+// talk to
+var torrentClients = map[string]struct {
+	url   string
+	stats func(base string) (torrentStats, error)
+}{
+	"transmission": {"http://localhost:9091", transmissionStats},
+	"qbittorrent":  {"http://localhost:8080", qbittorrentStats},
+}
+
+var torrent struct {
+	sync.Mutex
+	client    *http.Client
+	sessionID string // Transmission's CSRF token
+}
+
+// updateTorrent shows the number of active torrents and the rates they
+// download and upload with. It hides itself while the client isn't running or
+// no torrent is active.
+func updateTorrent() string {
+	var client, ok = torrentClients[cfg.Torrent.Client]
+	if !ok {
+		return ""
+	}
+	var base = strings.TrimSuffix(cfg.Torrent.URL, "/")
+	if base == "" {
+		base = client.url
+	}
+
+	torrent.Lock()
+	defer torrent.Unlock()
+	if torrent.client == nil {
+		var jar, _ = cookiejar.New(nil)
+		torrent.client = &http.Client{Timeout: 10 * time.Second, Jar: jar}
+	}
+	var stats, err = client.stats(base)
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ""
+	} else if err != nil {
+		return torrentSign + " ERR"
+	}
+	if stats.active == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d %s %s", torrentSign, stats.active,
+		fixed(netReceivedSign, stats.down), fixed(netTransmittedSign, stats.up))
+}
+
+// torrentPassword runs the configured password command
+func torrentPassword() (string, error) {
+	if cfg.Torrent.PasswordCommand == "" {
+		return "", nil
+	}
+	return secret(cfg.Torrent.PasswordCommand)
+}
+
+// transmissionStats calls session-stats on Transmission's RPC interface.
+// Transmission rejects requests without its current session id, which it
+// sends along with the rejection.
+func transmissionStats(base string) (torrentStats, error) {
+	var stats torrentStats
+	var password, err = torrentPassword()
+	if err != nil {
+		return stats, err
+	}
+	var resp *http.Response
+	for try := 0; try < 2; try++ {
+		req, err := http.NewRequest("POST", base+"/transmission/rpc",
+			bytes.NewBufferString(`{"method": "session-stats"}`))
+		if err != nil {
+			return stats, err
+		}
+		req.Header.Set("X-Transmission-Session-Id", torrent.sessionID)
+		if cfg.Torrent.User != "" {
+			req.SetBasicAuth(cfg.Torrent.User, password)
+		}
+		if resp, err = torrent.client.Do(req); err != nil {
+			return stats, err
+		}
+		if resp.StatusCode != http.StatusConflict {
+			break
+		}
+		resp.Body.Close()
+		torrent.sessionID = resp.Header.Get("X-Transmission-Session-Id")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return stats, fmt.Errorf("transmission: %s", resp.Status)
+	}
+
+	var reply struct {
+		Result    string `json:"result"`
+		Arguments struct {
+			Active int `json:"activeTorrentCount"`
+			Down   int `json:"downloadSpeed"`
+			Up     int `json:"uploadSpeed"`
+		} `json:"arguments"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return stats, err
+	} else if reply.Result != "success" {
+		return stats, fmt.Errorf("transmission: %s", reply.Result)
+	}
+	return torrentStats{reply.Arguments.Active, reply.Arguments.Down, reply.Arguments.Up}, nil
+}
+
+// qbittorrentStats reads the transfer rates and active torrents from the
+// qBittorrent Web API, logging in first if the session cookie is missing
+// or expired. Clients that don't ask localhost to log in need no user.
+func qbittorrentStats(base string) (torrentStats, error) {
+	var stats torrentStats
+	var transfer struct {
+		Down int `json:"dl_info_speed"`
+		Up   int `json:"up_info_speed"`
+	}
+	var err = qbittorrentGet(base, "/api/v2/transfer/info", &transfer)
+	if err == errForbidden && cfg.Torrent.User != "" {
+		if err = qbittorrentLogin(base); err == nil {
+			err = qbittorrentGet(base, "/api/v2/transfer/info", &transfer)
+		}
+	}
+	if err != nil {
+		return stats, err
+	}
+	var active []struct{}
+	if err = qbittorrentGet(base, "/api/v2/torrents/info?filter=active", &active); err != nil {
+		return stats, err
+	}
+	return torrentStats{len(active), transfer.Down, transfer.Up}, nil
+}
+
+// errForbidden is returned by the qBittorrent API without a valid session
+var errForbidden = errors.New("qbittorrent: forbidden")
+
+// qbittorrentGet decodes the JSON reply of a qBittorrent API call into v
+func qbittorrentGet(base, path string, v interface{}) error {
+	var resp, err = torrent.client.Get(base + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusForbidden:
+		return errForbidden
+	}
+	return fmt.Errorf("qbittorrent: %s", resp.Status)
+}
+
+// qbittorrentLogin gets a session cookie, which the client's jar keeps
+func qbittorrentLogin(base string) error {
+	var password, err = torrentPassword()
+	if err != nil {
+		return err
+	}
+	// qBittorrent checks the Referer against its own address
+	var form = url.Values{"username": {cfg.Torrent.User}, "password": {password}}
+	req, err := http.NewRequest("POST", base+"/api/v2/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", base)
+	resp, err := torrent.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(buf.String()) != "Ok." {
+		return fmt.Errorf("qbittorrent: login failed")
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	transitSign = ""
+)
+
+// transitConfig selects the stop whose next departure is shown
+type transitConfig struct {
+	// Provider is "transport.rest" for the HAFAS based APIs of
+	// transport.rest or "gtfs-rt" for a GTFS-realtime trip updates feed
+	Provider string `json:"provider"`
+	// API is the base URL of the transport.rest API or the URL of the
+	// GTFS-realtime feed
+	API  string `json:"api"`
+	Stop string `json:"stop"`
+	// Lines only shows these lines if set
+	Lines []string `json:"lines,omitempty"`
+	// Routes names the route ids of a GTFS-realtime feed, e.g. "Bus 12"
+	Routes map[string]string `json:"routes,omitempty"`
+	// Walk skips the departures you can't make it to anymore
+	Walk duration `json:"walk"`
+	// Rotate alternates the departure with the clock instead of showing
+	// both at once
+	Rotate  duration `json:"rotate"`
+	Refresh duration `json:"refresh"`
+}
+
+// departure is a line leaving the stop, delays included
+type departure struct {
+	line string
+	when time.Time
+}
+
+var transitProviders = map[string]func() ([]departure, error){
+	"transport.rest": fetchTransportRest,
+	"gtfs-rt":        fetchGTFSRealtime,
+}
+
+var transit struct {
+	sync.Mutex
+	departures []departure
+	fetched    bool
+	poll       poller
+}
+
+// updateTransit shows the next departure you can still catch, like
+// "Bus 12 in 6m". It hides itself if there is none and, if rotating, while it
+// is the clock's turn.
+func updateTransit() string {
+	transit.Lock()
+	if transit.poll.due() {
+		transit.poll.refresh = cfg.Transit.Refresh.Duration
+		var departures, err = fetchDepartures()
+		transit.poll.done(err)
+		if err == nil {
+			transit.departures, transit.fetched = departures, true
+		}
+	}
+	var fetched = transit.fetched
+	transit.Unlock()
+
+	var now = time.Now()
+	var next, ok = nextDeparture(now)
+	switch {
+	case !fetched:
+		return transitSign + " ERR"
+	case !ok || (cfg.Transit.Rotate.Duration > 0 && !transitTurn(now)):
+		return ""
+	}
+	return fmt.Sprintf("%s %s in %dm", transitSign, next.line, int(next.when.Sub(now).Minutes()))
+}
+
+// transitTurn reports whether a departure is shown in place of the clock
+func transitTurn(now time.Time) bool {
+	if cfg.Transit.Rotate.Duration <= 0 {
+		return false
+	}
+	var rotate = int64(cfg.Transit.Rotate.Seconds())
+	if rotate < 1 {
+		rotate = 1
+	}
+	var _, ok = nextDeparture(now)
+	return ok && now.Unix()/rotate%2 == 1
+}
+
+// nextDeparture returns the first departure leaving after the walk to the
+// stop
+func nextDeparture(now time.Time) (departure, bool) {
+	transit.Lock()
+	defer transit.Unlock()
+	for _, d := range transit.departures {
+		if d.when.Sub(now) >= cfg.Transit.Walk.Duration {
+			return d, true
+		}
+	}
+	return departure{}, false
+}
+
+// fetchDepartures asks the configured provider for the upcoming departures
+// of the wanted lines, sorted by time
+func fetchDepartures() ([]departure, error) {
+	var fetch, ok = transitProviders[cfg.Transit.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown transit provider %q", cfg.Transit.Provider)
+	}
+	var all, err = fetch()
+	if err != nil {
+		return nil, err
+	}
+	var departures []departure
+	for _, d := range all {
+		var wanted = len(cfg.Transit.Lines) == 0
+		for _, line := range cfg.Transit.Lines {
+			wanted = wanted || line == d.line
+		}
+		if wanted {
+			departures = append(departures, d)
+		}
+	}
+	sort.Slice(departures, func(i, j int) bool { return departures[i].when.Before(departures[j].when) })
+	return departures, nil
+}
+
+// fetchTransportRest queries the departures endpoint of a transport.rest API
+func fetchTransportRest() ([]departure, error) {
+	var result struct {
+		Departures []struct {
+			When *time.Time
+			Line struct {
+				Name string
+			}
+		}
+	}
+	var err = getJSON(weatherClient, cfg.Transit.API+"/stops/"+url.PathEscape(cfg.Transit.Stop)+"/departures?duration=90", &result)
+	if err != nil {
+		return nil, err
+	}
+	var departures []departure
+	for _, d := range result.Departures {
+		// cancelled departures have no time
+		if d.When != nil {
+			departures = append(departures, departure{d.Line.Name, *d.When})
+		}
+	}
+	return departures, nil
+}
+
+// fetchGTFSRealtime reads the stop's departures from the trip updates of a
+// GTFS-realtime feed
+func fetchGTFSRealtime() ([]departure, error) {
+	var resp, err = weatherClient.Get(cfg.Transit.API)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s: %s", resp.Request.URL.Host, resp.Status)
+	}
+	feed, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var departures []departure
+	// FeedMessage.entity → FeedEntity.trip_update
+	err = protoFields(feed, func(num int, value uint64, data []byte) error {
+		if num != 2 {
+			return nil
+		}
+		return protoFields(data, func(num int, value uint64, data []byte) error {
+			if num != 3 {
+				return nil
+			}
+			var d, err = parseTripUpdate(data)
+			if err == nil && !d.when.IsZero() {
+				departures = append(departures, d)
+			}
+			return err
+		})
+	})
+	return departures, err
+}
+
+// parseTripUpdate returns the route and departure time of a trip at the
+// configured stop, the time is zero if the trip doesn't stop there
+func parseTripUpdate(update []byte) (departure, error) {
+	var d departure
+	var err = protoFields(update, func(num int, value uint64, data []byte) error {
+		switch num {
+		case 1: // TripDescriptor.route_id
+			return protoFields(data, func(num int, value uint64, data []byte) error {
+				if num == 5 {
+					d.line = string(data)
+				}
+				return nil
+			})
+		case 2: // StopTimeUpdate.stop_id and its arrival or departure
+			var stop string
+			var times = map[int]int64{}
+			var err = protoFields(data, func(num int, value uint64, data []byte) error {
+				switch num {
+				case 2, 3:
+					var event = num
+					return protoFields(data, func(num int, value uint64, data []byte) error {
+						if num == 2 { // StopTimeEvent.time
+							times[event] = int64(value)
+						}
+						return nil
+					})
+				case 4:
+					stop = string(data)
+				}
+				return nil
+			})
+			if stop != cfg.Transit.Stop {
+				return err
+			}
+			// the departure is more accurate, but the last stop has none
+			if when, ok := times[3]; ok {
+				d.when = time.Unix(when, 0)
+			} else if when, ok := times[2]; ok {
+				d.when = time.Unix(when, 0)
+			}
+			return err
+		}
+		return nil
+	})
+	if name, ok := cfg.Transit.Routes[d.line]; ok && err == nil {
+		d.line = name
+	}
+	return d, err
+}
+
+// protoFields calls f for every field of a protocol buffers message, with
+// the value of varint and fixed size fields or the data of length delimited
+// ones
+func protoFields(msg []byte, f func(num int, value uint64, data []byte) error) error {
+	for len(msg) > 0 {
+		var key, n = binary.Uvarint(msg)
+		if n <= 0 {
+			return fmt.Errorf("protobuf: bad field key")
+		}
+		msg = msg[n:]
+		var value uint64
+		var data []byte
+		switch key & 7 {
+		case 0:
+			if value, n = binary.Uvarint(msg); n <= 0 {
+				return fmt.Errorf("protobuf: bad varint")
+			}
+		case 1:
+			if n = 8; len(msg) < n {
+				return fmt.Errorf("protobuf: short fixed64")
+			}
+			value = binary.LittleEndian.Uint64(msg)
+		case 2:
+			var length uint64
+			if length, n = binary.Uvarint(msg); n <= 0 || uint64(len(msg)-n) < length {
+				return fmt.Errorf("protobuf: bad length")
+			}
+			data = msg[n : n+int(length)]
+			n += int(length)
+		case 5:
+			if n = 4; len(msg) < n {
+				return fmt.Errorf("protobuf: short fixed32")
+			}
+			value = uint64(binary.LittleEndian.Uint32(msg))
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", key&7)
+		}
+		msg = msg[n:]
+		if err := f(int(key>>3), value, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	updatesSign = ""
+)
+
+// updateBackends are tried in order, the first package manager found in
+// $PATH is used to count the pending updates and how many of them fix
+// security issues
+var updateBackends = []struct {
+	name  string
+	count func() (updates, security int, err error)
+}{
+	{"checkupdates", countCheckupdates},
+	{"apt", countApt},
+	{"dnf", countDnf},
+}
+
+// aurHelpers can list the outdated foreign packages on their own, without
+// them the AUR RPC interface is queried directly
+var aurHelpers = []string{"paru", "yay"}
+
+const aurRPC = "https://aur.archlinux.org/rpc/?v=5&type=info"
+
+var aurClient = &http.Client{Timeout: 30 * time.Second}
+
+// updatePackages shows the number of pending package and flatpak updates, AUR
+// updates and security updates in red are counted separately. It hides itself
+// when the system is up to date.
+func updatePackages() string {
+	var count, security, err = countUpdates()
+	var flatpaks, flatpakErr = countFlatpak()
+	var aur, aurErr = countAUR()
+	if err != nil && flatpakErr != nil && aurErr != nil {
+		return updatesSign + " ERR"
+	}
+	count += flatpaks
+	notifyWhen("updates", count+aur > 0, "low", "Updates available",
+		fmt.Sprintf("%d package updates, %d security, %d AUR", count, security, aur))
+
+	var status = updatesSign
+	switch {
+	case count == 0 && aur == 0:
+		return ""
+	case count == 1:
+		status += " 1 update"
+	case count > 1:
+		status += fmt.Sprintf(" %d updates", count)
+	}
+	if security > 0 {
+		status += fmt.Sprintf(" %s%d security%s", colorUrgent, security, colorNormal)
+	}
+	if aur > 0 {
+		status += fmt.Sprintf(" %d AUR", aur)
+	}
+	return status
+}
+
+// countUpdates asks the first available backend for the pending updates
+func countUpdates() (updates, security int, err error) {
+	for _, backend := range updateBackends {
+		if _, err := exec.LookPath(backend.name); err == nil {
+			return backend.count()
+		}
+	}
+	return 0, 0, fmt.Errorf("no package manager found")
+}
+
+// countCheckupdates uses pacman-contrib's checkupdates, which exits with 2
+// when there is nothing to update. Security updates are the packages
+// arch-audit knows a fixed advisory for, if it is installed.
+func countCheckupdates() (updates, security int, err error) {
+	out, err := outputWithin(slowCommandTimeout, []int{2}, "checkupdates")
+	if err != nil {
+		return 0, 0, err
+	}
+	updates = countLines(out, func(line string) bool { return true })
+	if _, err := exec.LookPath("arch-audit"); err == nil {
+		if out, err := output(nil, "arch-audit", "--upgradable", "--quiet"); err == nil {
+			security = countLines(out, func(line string) bool { return true })
+		}
+	}
+	return updates, security, nil
+}
+
+// countApt lists the upgradable packages from apt's cached package lists,
+// those from a security pocket like bookworm-security are security updates
+func countApt() (updates, security int, err error) {
+	out, err := output(nil, "apt", "list", "--upgradable")
+	if err != nil {
+		return 0, 0, err
+	}
+	updates = countLines(out, func(line string) bool {
+		return strings.Contains(line, "[upgradable from")
+	})
+	security = countLines(out, func(line string) bool {
+		var fields = strings.Fields(line)
+		return strings.Contains(line, "[upgradable from") && strings.Contains(fields[0], "-security")
+	})
+	return updates, security, nil
+}
+
+// countDnf uses dnf check-update, which exits with 100 when updates are
+// available. Security updates are the packages named by a security advisory.
+func countDnf() (updates, security int, err error) {
+	out, err := outputWithin(slowCommandTimeout, []int{100}, "dnf", "-q", "check-update")
+	if err != nil {
+		return 0, 0, err
+	}
+	updates = countLines(out, func(line string) bool {
+		return len(strings.Fields(line)) == 3 && !strings.HasPrefix(line, " ")
+	})
+	if out, err := outputWithin(slowCommandTimeout, nil, "dnf", "-q", "updateinfo", "list", "--updates", "--security"); err == nil {
+		// lines are "<advisory> <severity>/Sec. <package>", one per advisory
+		var packages = map[string]bool{}
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Fields(line); len(fields) == 3 && strings.HasSuffix(fields[1], "Sec.") {
+				packages[fields[2]] = true
+			}
+		}
+		security = len(packages)
+	}
+	return updates, security, nil
+}
+
+// countFlatpak lists the apps and runtimes with updates on the configured
+// flatpak remotes
+func countFlatpak() (int, error) {
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		return 0, err
+	}
+	var out, err = outputWithin(slowCommandTimeout, nil, "flatpak", "remote-ls", "--updates", "--columns=application")
+	if err != nil {
+		return 0, err
+	}
+	return countLines(out, func(line string) bool { return true }), nil
+}
+
+// countAUR counts the outdated foreign packages using an AUR helper if one is
+// installed or the AUR RPC interface otherwise
+func countAUR() (int, error) {
+	for _, helper := range aurHelpers {
+		if _, err := exec.LookPath(helper); err == nil {
+			// like pacman, the helpers exit with 1 when nothing is outdated
+			var out, err = outputWithin(slowCommandTimeout, []int{1}, helper, "--query", "--upgrades", "--aur")
+			if err != nil {
+				return 0, err
+			}
+			return countLines(out, func(line string) bool { return true }), nil
+		}
+	}
+	if _, err := exec.LookPath("pacman"); err != nil {
+		return 0, err
+	}
+	return countAURRPC()
+}
+
+// countAURRPC compares the installed foreign packages against the versions
+// the AUR reports for them
+func countAURRPC() (int, error) {
+	var out, err = output([]int{1}, "pacman", "--query", "--foreign")
+	if err != nil {
+		return 0, err
+	}
+	var installed = map[string]string{}
+	var query = aurRPC
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			installed[fields[0]] = fields[1]
+			query += "&arg[]=" + url.QueryEscape(fields[0])
+		}
+	}
+	if len(installed) == 0 {
+		return 0, nil
+	}
+
+	resp, err := aurClient.Get(query)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Results []struct {
+			Name    string
+			Version string
+		} `json:"results"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return 0, err
+	}
+
+	var count = 0
+	for _, pkg := range info.Results {
+		// vercmp prints -1 if the first version is older
+		out, err := timedCommand("vercmp", installed[pkg.Name], pkg.Version).Output()
+		if err == nil && strings.TrimSpace(string(out)) == "-1" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// output runs a command and returns its stdout, the exit codes listed in ok
+// are not treated as errors
+func output(ok []int, name string, args ...string) ([]byte, error) {
+	return outputWithin(cfg.CommandTimeout.Duration, ok, name, args...)
+}
+
+// outputWithin is output with a timeout of its own
+func outputWithin(timeout time.Duration, ok []int, name string, args ...string) ([]byte, error) {
+	var out, err = commandWithin(timeout, name, args...).Output()
+	if exitErr, isExit := err.(*exec.ExitError); isExit {
+		for _, code := range ok {
+			if exitErr.ExitCode() == code {
+				return out, nil
+			}
+		}
+	}
+	return out, err
+}
+
+// countLines counts the non-empty lines of out accepted by keep
+func countLines(out []byte, keep func(string) bool) int {
+	var count = 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) != "" && keep(line) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fixedMetrics are recorded by the modules, along with disk:<mount> for each
+// of cfg.Disks
+var fixedMetrics = []string{"battery", "battery_energy", "cpu", "cputemp", "gputemp",
+	"mem", "net_rx", "net_tx", "online", "ping", "soctemp"}
+
+// validateConfig checks the names the config refers to, so a typo fails at
+// startup rather than showing up as ERR or silently doing nothing. Each
+// problem comes with the valid choices on this machine.
+func validateConfig() error {
+	var problems []string
+	var check = func(field, name string, valid []string) {
+		for _, v := range valid {
+			if name == v {
+				return
+			}
+		}
+		valid = append([]string(nil), valid...)
+		sort.Strings(valid)
+		problems = append(problems, fmt.Sprintf("%s: unknown %q, choose one of: %s",
+			field, name, strings.Join(valid, ", ")))
+	}
+
+	var outputNames, collectorNames, backendNames, torrentNames []string
+	var weatherNames, quoteNames, transitNames []string
+	for name := range outputs {
+		outputNames = append(outputNames, name)
+	}
+	for name := range collectors {
+		collectorNames = append(collectorNames, name)
+	}
+	for name := range volumeBackends {
+		backendNames = append(backendNames, name)
+	}
+	for name := range torrentClients {
+		torrentNames = append(torrentNames, name)
+	}
+	for name := range weatherProviders {
+		weatherNames = append(weatherNames, name)
+	}
+	for name := range quoteProviders {
+		quoteNames = append(quoteNames, name)
+	}
+	for name := range transitProviders {
+		transitNames = append(transitNames, name)
+	}
+	check("mode", cfg.Mode, []string{"compact", "expanded"})
+	check("output", cfg.Output, outputNames)
+	check("collector", cfg.Collector, collectorNames)
+	for _, name := range cfg.Volume.Backends {
+		check("volume.backends", name, backendNames)
+	}
+	if cfg.CommandTimeout.Duration <= 0 {
+		problems = append(problems, fmt.Sprintf("commandtimeout: %v has to be positive", cfg.CommandTimeout))
+	}
+	check("weather.provider", cfg.Weather.Provider, weatherNames)
+	check("airquality.provider", cfg.AirQuality.Provider, []string{"open-meteo", "waqi"})
+	check("quotes.provider", cfg.Quotes.Provider, quoteNames)
+	check("transit.provider", cfg.Transit.Provider, transitNames)
+	for _, clock := range cfg.Clocks {
+		if _, err := time.LoadLocation(clock.Timezone); err != nil {
+			problems = append(problems, fmt.Sprintf("clocks.timezone: %v, use an IANA name like \"America/New_York\"", err))
+		}
+	}
+	if rotate := cfg.Weather.Rotate.Duration; rotate > 0 && rotate < time.Second {
+		problems = append(problems, fmt.Sprintf("weather.rotate: %v has to be at least 1s", cfg.Weather.Rotate))
+	}
+	var dayNames []string
+	for name := range weekdays {
+		dayNames = append(dayNames, name)
+	}
+	for _, alarm := range cfg.Alarm.Alarms {
+		for _, day := range alarm.Days {
+			check("alarm.alarms.days", strings.ToLower(day), dayNames)
+		}
+	}
+	if cfg.MQTT.PasswordCommand != "" && cfg.MQTT.User == "" {
+		problems = append(problems, "mqtt.passwordcommand: needs mqtt.user, brokers refuse a password without a user name")
+	}
+	check("power.desktop", cfg.Power.Desktop, []string{"hide", "plugged"})
+	if cfg.Torrent.Client != "" {
+		check("torrent.client", cfg.Torrent.Client, torrentNames)
+	}
+
+	var names []string
+	for _, m := range modules {
+		names = append(names, m.name)
+	}
+	for _, name := range cfg.Hide {
+		check("hide", name, names)
+	}
+	for _, name := range cfg.Enable {
+		check("enable", name, names)
+	}
+	for source, profile := range cfg.Profiles {
+		check("profiles", source, []string{"ac", "battery"})
+		for _, name := range profile.Hide {
+			check("profiles."+source+".hide", name, names)
+		}
+	}
+	for _, name := range cfg.Carousel.Modules {
+		check("carousel.modules", name, names)
+	}
+	for name := range cfg.Click.Commands {
+		check("click.commands", name, names)
+	}
+	for name := range cfg.Click.Actions {
+		check("click.actions", name, names)
+	}
+
+	var metrics = append([]string(nil), fixedMetrics...)
+	for _, disk := range cfg.Disks {
+		if info, err := os.Stat(disk.Mount); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("disks: %q is no directory, mounted are: %s",
+				disk.Mount, strings.Join(mountPoints(), ", ")))
+		}
+		metrics = append(metrics, "disk:"+disk.Mount)
+	}
+	for _, rule := range cfg.Rules {
+		check("rules.metric", rule.Metric, metrics)
+	}
+	for _, lists := range []struct {
+		what    string
+		metrics []string
+	}{
+		{"history", cfg.History.Metrics},
+		{"statsd", cfg.StatsD.Metrics},
+		{"influx", cfg.Influx.Metrics},
+	} {
+		for _, metric := range lists.metrics {
+			check(lists.what+".metrics", metric, metrics)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s:\n\t%s", configPath(), strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// mountPoints lists the mounted file systems, pseudo ones left out
+func mountPoints() []string {
+	var content, err = ioutil.ReadFile(procPath("/proc/mounts"))
+	if err != nil {
+		return []string{"(unknown)"}
+	}
+	var mounts []string
+	for _, line := range strings.Split(string(content), "\n") {
+		var fields = strings.Fields(line)
+		if len(fields) >= 2 && strings.HasPrefix(fields[0], "/") {
+			mounts = append(mounts, fields[1])
+		}
+	}
+	return mounts
+}
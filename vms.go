@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+)
+
+const (
+	vmSign = ""
+)
+
+// libvirtURIs are the hypervisor connections asked for running domains
+var libvirtURIs = []string{"qemu:///system", "qemu:///session"}
+
+// updateVMs shows the number of running virtual machines and hides itself if
+// there are none
+func updateVMs() string {
+	var count, err = countLibvirtDomains()
+	if err != nil {
+		count = countQemuProcesses()
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %d", vmSign, count)
+}
+
+// countLibvirtDomains asks libvirt for the running domains of the system and
+// the user session
+func countLibvirtDomains() (int, error) {
+	if _, err := exec.LookPath("virsh"); err != nil {
+		return 0, err
+	}
+	var count, failed = 0, 0
+	for _, uri := range libvirtURIs {
+		var out, err = output(nil, "virsh", "--connect", uri, "list", "--name")
+		if err != nil {
+			failed++
+			continue
+		}
+		count += countLines(out, func(line string) bool { return true })
+	}
+	if failed == len(libvirtURIs) {
+		return 0, fmt.Errorf("libvirt not reachable")
+	}
+	return count, nil
+}
+
+// countQemuProcesses counts the qemu emulators running without libvirt
+func countQemuProcesses() int {
+	var procs, err = ioutil.ReadDir(procPath("/proc"))
+	if err != nil {
+		return 0
+	}
+	var count = 0
+	for _, proc := range procs {
+		comm, err := ioutil.ReadFile(procPath("/proc/" + proc.Name() + "/comm"))
+		if err == nil && strings.HasPrefix(string(comm), "qemu-system") {
+			count++
+		}
+	}
+	return count
+}
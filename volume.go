@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// volumeStep is how many percent `volume up` and `volume down` change the
+// volume
+const volumeStep = 5
+
+// volumeConfig selects the sound system
+type volumeConfig struct {
+	// Backends are tried in order, the first that works is used: "pipewire",
+	// "pulse" for PulseAudio and PipeWire, "sndio" or "alsa". The default
+	// depends on the operating system.
+	Backends []string `json:"backends"`
+}
+
+// volumeBackend reads and changes the volume of a sound system
+type volumeBackend struct {
+	read func() (percent int, muted bool, err error)
+	// change does "mute", "up" or "down"
+	change func(action string) error
+}
+
+var volumeBackends = map[string]volumeBackend{
+	"pipewire": {pipewireVolume, pipewireCommand},
+	"pulse":    {pulseVolume, pulseCommand},
+	"sndio":    {sndioVolume, sndioCommand},
+	"alsa":     {alsaVolume, alsaCommand},
+}
+
+// volumeFallback remembers the first of cfg.Volume.Backends that works
+var volumeFallback fallback
+
+// readVolume reads the volume through the first backend that works
+func readVolume() (percent int, muted bool, err error) {
+	err = volumeFallback.try(cfg.Volume.Backends, func(name string) error {
+		var err error
+		percent, muted, err = volumeBackends[name].read()
+		return err
+	})
+	return percent, muted, err
+}
+
+// volumeCommand mutes the volume or changes it by volumeStep
+func volumeCommand(args []string) (string, error) {
+	var action = strings.Join(args, " ")
+	if action != "mute" && action != "up" && action != "down" {
+		return "", fmt.Errorf("usage: volume mute|up|down")
+	}
+	var err = volumeFallback.try(cfg.Volume.Backends, func(name string) error {
+		return volumeBackends[name].change(action)
+	})
+	if err != nil {
+		return "", err
+	}
+	refresh("volume")
+	return "", nil
+}
+
+// pulseVolume reads the volume of the default sink through pactl. Its output
+// is translated, so it is run in the C locale:
+//
+//	Volume: front-left: 32768 /  50% / -18.06 dB,   front-right: 32768 /  50% / -18.06 dB
+//	Mute: no
+func pulseVolume() (percent int, muted bool, err error) {
+	volume, err := pactl("get-sink-volume", "@DEFAULT_SINK@")
+	if err != nil {
+		return 0, false, err
+	}
+	var match = pulseVolumeRx.FindStringSubmatch(volume)
+	if match == nil {
+		return 0, false, fmt.Errorf("pactl: no volume in %q", volume)
+	}
+	percent, err = strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false, err
+	}
+	mute, err := pactl("get-sink-mute", "@DEFAULT_SINK@")
+	if err != nil {
+		return 0, false, err
+	}
+	switch strings.TrimSpace(mute) {
+	case "Mute: yes":
+		return percent, true, nil
+	case "Mute: no":
+		return percent, false, nil
+	}
+	return 0, false, fmt.Errorf("pactl: no mute state in %q", mute)
+}
+
+// pulseVolumeRx matches the volume of the first channel
+var pulseVolumeRx = regexp.MustCompile(`(\d+)%`)
+
+// pactl runs a pactl command in the C locale
+func pactl(args ...string) (string, error) {
+	var cmd = timedCommand("pactl", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var out, err = cmd.Output()
+	return string(out), err
+}
+
+// pulseCommand changes the volume of the default sink through pactl, which
+// works with PulseAudio and PipeWire alike
+func pulseCommand(action string) error {
+	var pactl = map[string][]string{
+		"mute": {"set-sink-mute", "@DEFAULT_SINK@", "toggle"},
+		"up":   {"set-sink-volume", "@DEFAULT_SINK@", fmt.Sprintf("+%d%%", volumeStep)},
+		"down": {"set-sink-volume", "@DEFAULT_SINK@", fmt.Sprintf("-%d%%", volumeStep)},
+	}[action]
+	if out, err := timedCommand("pactl", pactl...).CombinedOutput(); err != nil {
+		return fmt.Errorf("pactl: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pipewireVolume reads the volume of the default sink through wpctl, e.g.
+// "Volume: 0.40 [MUTED]"
+func pipewireVolume() (percent int, muted bool, err error) {
+	out, err := timedCommand("wpctl", "get-volume", "@DEFAULT_AUDIO_SINK@").Output()
+	if err != nil {
+		return 0, false, err
+	}
+	var volume float64
+	if _, err = fmt.Sscanf(string(out), "Volume: %f", &volume); err != nil {
+		return 0, false, fmt.Errorf("wpctl: no volume in %q", out)
+	}
+	return int(volume*100 + 0.5), strings.Contains(string(out), "[MUTED]"), nil
+}
+
+// pipewireCommand changes the volume of the default sink through wpctl
+func pipewireCommand(action string) error {
+	var wpctl = map[string][]string{
+		"mute": {"set-mute", "@DEFAULT_AUDIO_SINK@", "toggle"},
+		"up":   {"set-volume", "@DEFAULT_AUDIO_SINK@", fmt.Sprintf("%d%%+", volumeStep)},
+		"down": {"set-volume", "@DEFAULT_AUDIO_SINK@", fmt.Sprintf("%d%%-", volumeStep)},
+	}[action]
+	if out, err := timedCommand("wpctl", wpctl...).CombinedOutput(); err != nil {
+		return fmt.Errorf("wpctl: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// sndioVolume reads the output level and mute control of the default sndio
+// device through sndioctl, the command line front end of sioctl_open(3)
+func sndioVolume() (percent int, muted bool, err error) {
+	out, err := timedCommand("sndioctl", "-n", "output.level", "output.mute").Output()
+	if err != nil {
+		return 0, false, err
+	}
+	var level float64
+	var mute int
+	if _, err = fmt.Sscanf(string(out), "%f\n%d", &level, &mute); err != nil {
+		return 0, false, err
+	}
+	return int(level*100 + 0.5), mute == 1, nil
+}
+
+// sndioCommand changes the output level or toggles the mute control
+func sndioCommand(action string) error {
+	var control = map[string]string{
+		"mute": "output.mute=!",
+		"up":   fmt.Sprintf("output.level=+%.2f", float64(volumeStep)/100),
+		"down": fmt.Sprintf("output.level=-%.2f", float64(volumeStep)/100),
+	}[action]
+	if out, err := timedCommand("sndioctl", "-q", control).CombinedOutput(); err != nil {
+		return fmt.Errorf("sndioctl: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// alsaVolume reads the Master control of the default card through amixer:
+//
+//	Front Left: Playback 45 [70%] [-12.00dB] [on]
+func alsaVolume() (percent int, muted bool, err error) {
+	out, err := timedCommand("amixer", "get", "Master").Output()
+	if err != nil {
+		return 0, false, err
+	}
+	var match = alsaVolumeRx.FindStringSubmatch(string(out))
+	if match == nil {
+		return 0, false, fmt.Errorf("amixer: no volume of Master")
+	}
+	percent, err = strconv.Atoi(match[1])
+	return percent, match[2] == "off", err
+}
+
+// alsaVolumeRx matches the volume and switch of the first channel
+var alsaVolumeRx = regexp.MustCompile(`\[(\d+)%\].*\[(on|off)\]`)
+
+// alsaCommand changes the Master control of the default card
+func alsaCommand(action string) error {
+	var value = map[string]string{
+		"mute": "toggle",
+		"up":   fmt.Sprintf("%d%%+", volumeStep),
+		"down": fmt.Sprintf("%d%%-", volumeStep),
+	}[action]
+	if out, err := timedCommand("amixer", "-q", "set", "Master", value).CombinedOutput(); err != nil {
+		return fmt.Errorf("amixer: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
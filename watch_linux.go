@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY
+
+// watch calls changed whenever the content of one of the directories changes
+// or one of the files is written or replaced. Files are watched through their
+// directory, so editors replacing them on save are noticed as well. watch
+// blocks, so run it in its own goroutine.
+func watch(paths []string, changed func()) error {
+	var fd, err = syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	// maps watch descriptors to the watched file names, "" for directories
+	var files = map[int32]map[string]bool{}
+	for _, path := range paths {
+		var dir, name = path, ""
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			dir, name = filepath.Dir(path), filepath.Base(path)
+		}
+		var wd, err = syscall.InotifyAddWatch(fd, dir, inotifyMask)
+		if err != nil {
+			return err
+		}
+		if files[int32(wd)] == nil {
+			files[int32(wd)] = map[string]bool{}
+		}
+		files[int32(wd)][name] = true
+	}
+
+	var buf [4096]byte
+	for {
+		var n, err = syscall.Read(fd, buf[:])
+		if err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var relevant = false
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			var event = (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			var nameBytes = buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			var name = string(nameBytes[:clen(nameBytes)])
+			if names := files[event.Wd]; names[""] || names[name] {
+				relevant = true
+			}
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+		}
+		if relevant {
+			changed()
+		}
+	}
+}
+
+// clen returns the length of a NUL terminated byte string
+func clen(b []byte) int {
+	for i, c := range b {
+		if c == 0 {
+			return i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// watchInterval is how often watch looks for changes without inotify
+const watchInterval = 5 * time.Second
+
+// watch calls changed whenever one of the paths is modified. Without inotify
+// the modification times are polled, which also catches new and removed
+// directory entries. watch blocks, so run it in its own goroutine.
+func watch(paths []string, changed func()) error {
+	var mtimes = map[string]time.Time{}
+	for {
+		var modified = false
+		for _, path := range paths {
+			var mtime time.Time
+			if info, err := os.Stat(path); err == nil {
+				mtime = info.ModTime()
+			}
+			if last, ok := mtimes[path]; ok && !last.Equal(mtime) {
+				modified = true
+			}
+			mtimes[path] = mtime
+		}
+		if modified {
+			changed()
+		}
+		time.Sleep(watchInterval)
+	}
+}
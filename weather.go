@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// weather condition signs
+var weatherSigns = map[string]string{
+	"clear":   "",
+	"clouds":  "",
+	"rain":    "",
+	"snow":    "",
+	"thunder": "",
+	"fog":     "",
+}
+
+const (
+	weatherAlertSign = ""
+
+	weatherStale = 3 * time.Hour // older cached data is marked as such
+)
+
+// weatherConfig selects the location and what is shown of the weather
+type weatherConfig struct {
+	// Provider is one of the weatherProviders
+	Provider string `json:"provider"`
+	// APIKey for providers needing one
+	APIKey string `json:"apikey,omitempty" secret:"true"`
+	// City like "Munich,DE", ignored if coordinates are given
+	City string  `json:"city,omitempty"`
+	Lat  float64 `json:"lat,omitempty"`
+	Lon  float64 `json:"lon,omitempty"`
+	// Locate determines the location automatically, the configured one is
+	// only used if that fails
+	Locate bool `json:"locate,omitempty"`
+	// Units is either metric or imperial
+	Units string `json:"units"`
+	// Wind adds the wind speed
+	Wind bool `json:"wind,omitempty"`
+	// Refresh is how often the weather is fetched
+	Refresh duration `json:"refresh"`
+	// Rotate alternates between the current weather and tomorrow's forecast
+	// every so often, 0 only shows the current weather
+	Rotate duration `json:"rotate"`
+}
+
+// weatherReport is the current weather at the configured location
+type weatherReport struct {
+	Time      time.Time `json:"time"`
+	Condition string    `json:"condition"` // one of the weatherSigns keys
+	Temp      float64   `json:"temp"`
+	Wind      float64   `json:"wind"` // m/s or mph
+	Tomorrow  forecast  `json:"tomorrow"`
+	Alerts    []string  `json:"alerts,omitempty"` // active weather warnings
+}
+
+// forecast is the weather expected for a whole day, the condition is empty if
+// the provider did not report a forecast
+type forecast struct {
+	Condition string  `json:"condition"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+}
+
+// weather holds whether the forecast is shown next to the current weather
+var weather struct {
+	sync.Mutex
+	expanded bool
+}
+
+var weatherClient = &http.Client{Timeout: 30 * time.Second}
+
+// lastWeather is the last report fetched, it is also kept on disk so it can
+// be shown right away after a restart while offline
+var lastWeather *weatherReport
+
+// weatherPoll backs off while the provider can't be reached
+var weatherPoll poller
+
+// updateWeather shows the temperature and condition, refreshed every
+// refresh interval. When fetching fails the last report is shown.
+func updateWeather() string {
+	if lastWeather == nil {
+		if lastWeather = loadWeather(); lastWeather != nil {
+			weatherPoll.next = lastWeather.Time.Add(cfg.Weather.Refresh.Duration)
+		}
+	}
+	if weatherPoll.due() {
+		weatherPoll.refresh = cfg.Weather.Refresh.Duration
+		var report, err = fetchWeather()
+		weatherPoll.done(err)
+		if err == nil {
+			lastWeather = &report
+			saveWeather(report)
+		}
+	}
+	if lastWeather == nil {
+		return weatherSigns["clouds"] + " ERR"
+	}
+	if alerts := lastWeather.Alerts; len(alerts) > 0 && time.Since(lastWeather.Time) < weatherStale {
+		var text = weatherAlertSign + " " + alerts[0]
+		if len(alerts) > 1 {
+			text += fmt.Sprintf(" +%d", len(alerts)-1)
+		}
+		return colorUrgent + text + colorNormal
+	}
+
+	weather.Lock()
+	defer weather.Unlock()
+	var tomorrow = lastWeather.Tomorrow
+	var rotate = int64(cfg.Weather.Rotate.Seconds())
+	if rotate < 1 {
+		rotate = 1
+	}
+	switch {
+	case tomorrow.Condition == "":
+	case weather.expanded:
+		return formatWeather(*lastWeather) + " " + formatForecast(tomorrow)
+	case cfg.Weather.Rotate.Duration > 0 && time.Now().Unix()/rotate%2 == 1:
+		return formatForecast(tomorrow)
+	}
+	return formatWeather(*lastWeather)
+}
+
+// weatherCommand shows or hides the forecast next to the current weather
+func weatherCommand(args []string) (string, error) {
+	if len(args) != 1 || args[0] != "toggle" {
+		return "", fmt.Errorf("usage: weather toggle")
+	}
+	weather.Lock()
+	weather.expanded = !weather.expanded
+	weather.Unlock()
+	refresh("weather")
+	return "", nil
+}
+
+// formatForecast renders tomorrow's forecast like "tmrw  18/9°C"
+func formatForecast(tomorrow forecast) string {
+	var unit = "°C"
+	if cfg.Weather.Units == "imperial" {
+		unit = "°F"
+	}
+	return fmt.Sprintf("tmrw %s %d/%d%s", weatherSigns[tomorrow.Condition],
+		int(math.Round(tomorrow.High)), int(math.Round(tomorrow.Low)), unit)
+}
+
+// formatWeather renders a report like " 21°C 3m/s"
+func formatWeather(report weatherReport) string {
+	var tempUnit, windUnit = "°C", "m/s"
+	if cfg.Weather.Units == "imperial" {
+		tempUnit, windUnit = "°F", "mph"
+	}
+	var text = fmt.Sprintf("%s %d%s", weatherSigns[report.Condition], int(math.Round(report.Temp)), tempUnit)
+	if cfg.Weather.Wind {
+		text += fmt.Sprintf(" %d%s", int(math.Round(report.Wind)), windUnit)
+	}
+	if time.Since(report.Time) > weatherStale {
+		text += "?"
+	}
+	return text
+}
+
+// fetchWeather asks the configured provider for the current weather
+func fetchWeather() (weatherReport, error) {
+	var provider, ok = weatherProviders[cfg.Weather.Provider]
+	if !ok {
+		return weatherReport{}, fmt.Errorf("unknown weather provider %q", cfg.Weather.Provider)
+	}
+	var report, err = provider.current()
+	if err != nil {
+		return report, err
+	}
+	report.Time = time.Now()
+	if alerter, ok := provider.(weatherAlerter); ok {
+		report.Alerts, _ = alerter.alerts()
+	}
+	return report, nil
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func getJSON(client *http.Client, url string, v interface{}) error {
+	var resp, err = client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeJSON(resp, v)
+}
+
+// decodeJSON checks the response status and decodes the JSON body into v
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Request.URL.Host, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// loadWeather reads the report saved by saveWeather
+func loadWeather() *weatherReport {
+	var data, err = ioutil.ReadFile(statePath("weather.json"))
+	if err != nil {
+		return nil
+	}
+	var report weatherReport
+	if json.Unmarshal(data, &report) != nil {
+		return nil
+	}
+	return &report
+}
+
+// saveWeather keeps the report across restarts
+func saveWeather(report weatherReport) {
+	var data, err = json.Marshal(report)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(statePath("weather.json")), 0755) == nil {
+		ioutil.WriteFile(statePath("weather.json"), data, 0644)
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// weatherProvider fetches the current weather for the configured location.
+// Temperatures are reported in °C or °F and wind speeds in m/s or mph,
+// depending on the configured units.
+type weatherProvider interface {
+	current() (weatherReport, error)
+}
+
+// weatherAlerter is implemented by providers reporting official weather
+// warnings for the location
+type weatherAlerter interface {
+	alerts() ([]string, error)
+}
+
+// weatherProviders can be selected in the config, only OpenWeatherMap needs
+// an API key
+var weatherProviders = map[string]weatherProvider{
+	"openweathermap": openWeatherMap{},
+	"open-meteo":     openMeteo{},
+	"met.no":         metNo{},
+	"wttr.in":        wttrIn{},
+}
+
+type openWeatherMap struct{}
+
+func (openWeatherMap) current() (weatherReport, error) {
+	var query = url.Values{"appid": {cfg.Weather.APIKey}, "units": {cfg.Weather.Units}}
+	if lat, lon, ok := knownCoordinates(); ok {
+		query.Set("lat", fmt.Sprint(lat))
+		query.Set("lon", fmt.Sprint(lon))
+	} else {
+		query.Set("q", cfg.Weather.City)
+	}
+
+	var data struct {
+		Weather []struct{ ID int }
+		Main    struct{ Temp float64 }
+		Wind    struct{ Speed float64 }
+	}
+	if err := getJSON(weatherClient, "https://api.openweathermap.org/data/2.5/weather?"+query.Encode(), &data); err != nil {
+		return weatherReport{}, err
+	}
+	if len(data.Weather) == 0 {
+		return weatherReport{}, fmt.Errorf("openweathermap: no conditions")
+	}
+
+	// the free forecast comes in steps of three hours
+	var forecast struct {
+		List []struct {
+			Dt      int64
+			Weather []struct{ ID int }
+			Main    struct{ Temp float64 }
+		}
+	}
+	var tomorrow = newTomorrow()
+	if getJSON(weatherClient, "https://api.openweathermap.org/data/2.5/forecast?"+query.Encode(), &forecast) == nil {
+		for _, step := range forecast.List {
+			if len(step.Weather) > 0 {
+				tomorrow.add(time.Unix(step.Dt, 0), step.Main.Temp, owmCondition(step.Weather[0].ID))
+			}
+		}
+	}
+	return weatherReport{
+		Condition: owmCondition(data.Weather[0].ID),
+		Temp:      data.Main.Temp,
+		Wind:      data.Wind.Speed,
+		Tomorrow:  tomorrow.forecast,
+	}, nil
+}
+
+// alerts uses the One Call API, which needs a subscription to the One Call
+// plan
+func (openWeatherMap) alerts() ([]string, error) {
+	var lat, lon, err = weatherCoordinates()
+	if err != nil {
+		return nil, err
+	}
+	var query = url.Values{
+		"appid":   {cfg.Weather.APIKey},
+		"lat":     {fmt.Sprint(lat)},
+		"lon":     {fmt.Sprint(lon)},
+		"exclude": {"current,minutely,hourly,daily"},
+	}
+	var data struct {
+		Alerts []struct{ Event string }
+	}
+	if err = getJSON(weatherClient, "https://api.openweathermap.org/data/3.0/onecall?"+query.Encode(), &data); err != nil {
+		return nil, err
+	}
+	var alerts []string
+	for _, alert := range data.Alerts {
+		alerts = append(alerts, alert.Event)
+	}
+	return alerts, nil
+}
+
+// owmCondition maps OpenWeatherMap's condition codes to weatherSigns
+func owmCondition(id int) string {
+	switch {
+	case id < 300:
+		return "thunder"
+	case id < 600:
+		return "rain"
+	case id < 700:
+		return "snow"
+	case id < 800:
+		return "fog"
+	case id == 800:
+		return "clear"
+	}
+	return "clouds"
+}
+
+type openMeteo struct{}
+
+func (openMeteo) current() (weatherReport, error) {
+	var lat, lon, err = weatherCoordinates()
+	if err != nil {
+		return weatherReport{}, err
+	}
+	var query = url.Values{
+		"latitude":        {fmt.Sprint(lat)},
+		"longitude":       {fmt.Sprint(lon)},
+		"current":         {"temperature_2m,weather_code,wind_speed_10m"},
+		"daily":           {"weather_code,temperature_2m_max,temperature_2m_min"},
+		"forecast_days":   {"2"},
+		"timezone":        {"auto"},
+		"wind_speed_unit": {"ms"},
+	}
+	if cfg.Weather.Units == "imperial" {
+		query.Set("temperature_unit", "fahrenheit")
+		query.Set("wind_speed_unit", "mph")
+	}
+
+	var data struct {
+		Current struct {
+			Temperature float64 `json:"temperature_2m"`
+			WeatherCode int     `json:"weather_code"`
+			WindSpeed   float64 `json:"wind_speed_10m"`
+		}
+		Daily struct {
+			WeatherCode []int     `json:"weather_code"`
+			High        []float64 `json:"temperature_2m_max"`
+			Low         []float64 `json:"temperature_2m_min"`
+		}
+	}
+	if err = getJSON(weatherClient, "https://api.open-meteo.com/v1/forecast?"+query.Encode(), &data); err != nil {
+		return weatherReport{}, err
+	}
+	var report = weatherReport{
+		Condition: wmoCondition(data.Current.WeatherCode),
+		Temp:      data.Current.Temperature,
+		Wind:      data.Current.WindSpeed,
+	}
+	if daily := data.Daily; len(daily.WeatherCode) > 1 && len(daily.High) > 1 && len(daily.Low) > 1 {
+		report.Tomorrow = forecast{wmoCondition(daily.WeatherCode[1]), daily.High[1], daily.Low[1]}
+	}
+	return report, nil
+}
+
+// wmoCondition maps the WMO weather interpretation codes to weatherSigns
+func wmoCondition(code int) string {
+	switch {
+	case code <= 1:
+		return "clear"
+	case code <= 3:
+		return "clouds"
+	case code <= 48:
+		return "fog"
+	case code <= 67 || code >= 80 && code <= 82:
+		return "rain"
+	case code <= 86:
+		return "snow"
+	}
+	return "thunder"
+}
+
+type metNo struct{}
+
+func (metNo) current() (weatherReport, error) {
+	var lat, lon, err = weatherCoordinates()
+	if err != nil {
+		return weatherReport{}, err
+	}
+	// met.no requires an identifying user agent
+	var req, _ = http.NewRequest("GET", fmt.Sprintf(
+		"https://api.met.no/weatherapi/locationforecast/2.0/compact?lat=%.4f&lon=%.4f", lat, lon), nil)
+	req.Header.Set("User-Agent", "gods github.com/schachmat/gods")
+	resp, err := weatherClient.Do(req)
+	if err != nil {
+		return weatherReport{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return weatherReport{}, fmt.Errorf("met.no: %s", resp.Status)
+	}
+
+	var data struct {
+		Properties struct {
+			Timeseries []struct {
+				Time time.Time
+				Data struct {
+					Instant struct {
+						Details struct {
+							AirTemperature float64 `json:"air_temperature"`
+							WindSpeed      float64 `json:"wind_speed"`
+						}
+					}
+					Next1Hours struct {
+						Summary struct {
+							SymbolCode string `json:"symbol_code"`
+						}
+					} `json:"next_1_hours"`
+					Next6Hours struct {
+						Summary struct {
+							SymbolCode string `json:"symbol_code"`
+						}
+					} `json:"next_6_hours"`
+				}
+			}
+		}
+	}
+	if err = decodeJSON(resp, &data); err != nil {
+		return weatherReport{}, err
+	}
+	if len(data.Properties.Timeseries) == 0 {
+		return weatherReport{}, fmt.Errorf("met.no: no forecast")
+	}
+	var now = data.Properties.Timeseries[0].Data
+	var report = weatherReport{
+		Condition: textCondition(now.Next1Hours.Summary.SymbolCode),
+		Temp:      now.Instant.Details.AirTemperature,
+		Wind:      now.Instant.Details.WindSpeed,
+	}
+	var tomorrow = newTomorrow()
+	for _, step := range data.Properties.Timeseries {
+		var symbol = step.Data.Next1Hours.Summary.SymbolCode
+		if symbol == "" {
+			symbol = step.Data.Next6Hours.Summary.SymbolCode
+		}
+		tomorrow.add(step.Time, step.Data.Instant.Details.AirTemperature, textCondition(symbol))
+	}
+	report.Tomorrow = tomorrow.forecast
+
+	if cfg.Weather.Units == "imperial" {
+		report.Temp = celsiusToFahrenheit(report.Temp)
+		report.Wind *= 2.23694
+		report.Tomorrow.High = celsiusToFahrenheit(report.Tomorrow.High)
+		report.Tomorrow.Low = celsiusToFahrenheit(report.Tomorrow.Low)
+	}
+	return report, nil
+}
+
+// alerts uses MetAlerts, which covers Norway only
+func (metNo) alerts() ([]string, error) {
+	var lat, lon, err = weatherCoordinates()
+	if err != nil {
+		return nil, err
+	}
+	var req, _ = http.NewRequest("GET", fmt.Sprintf(
+		"https://api.met.no/weatherapi/metalerts/2.0/current.json?lat=%.4f&lon=%.4f", lat, lon), nil)
+	req.Header.Set("User-Agent", "gods github.com/schachmat/gods")
+	resp, err := weatherClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Features []struct {
+			Properties struct {
+				Event              string
+				EventAwarenessName string
+			}
+		}
+	}
+	if err = decodeJSON(resp, &data); err != nil {
+		return nil, err
+	}
+	var alerts []string
+	for _, feature := range data.Features {
+		if name := feature.Properties.EventAwarenessName; name != "" {
+			alerts = append(alerts, name)
+		} else {
+			alerts = append(alerts, feature.Properties.Event)
+		}
+	}
+	return alerts, nil
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+type wttrIn struct{}
+
+func (wttrIn) current() (weatherReport, error) {
+	var location = url.PathEscape(cfg.Weather.City)
+	if lat, lon, ok := knownCoordinates(); ok {
+		location = fmt.Sprintf("%.4f,%.4f", lat, lon)
+	}
+
+	var data struct {
+		CurrentCondition []struct {
+			TempC         string `json:"temp_C"`
+			TempF         string `json:"temp_F"`
+			WindspeedKmph string `json:"windspeedKmph"`
+			WindspeedMph  string `json:"windspeedMiles"`
+			WeatherDesc   []struct{ Value string }
+		} `json:"current_condition"`
+		Weather []struct {
+			MaxtempC, MintempC, MaxtempF, MintempF string
+			Hourly                                 []struct {
+				Time        string
+				WeatherDesc []struct{ Value string }
+			}
+		}
+	}
+	if err := getJSON(weatherClient, "https://wttr.in/"+location+"?format=j1", &data); err != nil {
+		return weatherReport{}, err
+	}
+	if len(data.CurrentCondition) == 0 || len(data.CurrentCondition[0].WeatherDesc) == 0 {
+		return weatherReport{}, fmt.Errorf("wttr.in: no conditions")
+	}
+
+	var now = data.CurrentCondition[0]
+	var report = weatherReport{Condition: textCondition(now.WeatherDesc[0].Value)}
+	if cfg.Weather.Units == "imperial" {
+		fmt.Sscan(now.TempF, &report.Temp)
+		fmt.Sscan(now.WindspeedMph, &report.Wind)
+	} else {
+		fmt.Sscan(now.TempC, &report.Temp)
+		fmt.Sscan(now.WindspeedKmph, &report.Wind)
+		report.Wind /= 3.6
+	}
+
+	if len(data.Weather) > 1 {
+		var day = data.Weather[1]
+		for _, hour := range day.Hourly {
+			if hour.Time == "1200" && len(hour.WeatherDesc) > 0 {
+				report.Tomorrow.Condition = textCondition(hour.WeatherDesc[0].Value)
+			}
+		}
+		if cfg.Weather.Units == "imperial" {
+			fmt.Sscan(day.MaxtempF, &report.Tomorrow.High)
+			fmt.Sscan(day.MintempF, &report.Tomorrow.Low)
+		} else {
+			fmt.Sscan(day.MaxtempC, &report.Tomorrow.High)
+			fmt.Sscan(day.MintempC, &report.Tomorrow.Low)
+		}
+	}
+	return report, nil
+}
+
+// tomorrow collects tomorrow's forecast from samples at different times of
+// the day: the highest and lowest temperature and the condition closest to
+// noon
+type tomorrow struct {
+	forecast
+	noon    time.Time
+	nearest time.Duration // of the condition sample to noon
+	samples int
+}
+
+func newTomorrow() *tomorrow {
+	var y, m, d = time.Now().AddDate(0, 0, 1).Date()
+	return &tomorrow{noon: time.Date(y, m, d, 12, 0, 0, 0, time.Local)}
+}
+
+// add takes a sample into account if it is from tomorrow
+func (t *tomorrow) add(at time.Time, temp float64, condition string) {
+	var dist = at.Sub(t.noon)
+	if dist < -12*time.Hour || dist >= 12*time.Hour {
+		return
+	}
+	if t.samples == 0 || temp > t.High {
+		t.High = temp
+	}
+	if t.samples == 0 || temp < t.Low {
+		t.Low = temp
+	}
+	if dist < 0 {
+		dist = -dist
+	}
+	if t.samples == 0 || dist < t.nearest {
+		t.Condition, t.nearest = condition, dist
+	}
+	t.samples++
+}
+
+// textCondition maps a condition description like "Light rain shower" or
+// met.no's "lightrainshowers_day" to weatherSigns
+func textCondition(text string) string {
+	text = strings.ToLower(text)
+	for _, match := range []struct{ words, condition string }{
+		{"thunder", "thunder"},
+		{"snow sleet blizzard ice", "snow"},
+		{"rain drizzle shower", "rain"},
+		{"fog mist haze", "fog"},
+		{"clear sunny fair", "clear"},
+	} {
+		for _, word := range strings.Fields(match.words) {
+			if strings.Contains(text, word) {
+				return match.condition
+			}
+		}
+	}
+	return "clouds"
+}
+
+// knownCoordinates returns the automatically determined location, if enabled,
+// or else the configured coordinates, if any
+func knownCoordinates() (float64, float64, bool) {
+	if cfg.Weather.Locate {
+		if lat, lon, err := locate(); err == nil {
+			return lat, lon, true
+		}
+	}
+	if cfg.Weather.Lat != 0 || cfg.Weather.Lon != 0 {
+		return cfg.Weather.Lat, cfg.Weather.Lon, true
+	}
+	return 0, 0, false
+}
+
+// weatherCoordinates returns the known coordinates or looks up those of the
+// configured city with Open-Meteo's geocoding API
+func weatherCoordinates() (float64, float64, error) {
+	if lat, lon, ok := knownCoordinates(); ok {
+		return lat, lon, nil
+	}
+	var name = strings.Split(cfg.Weather.City, ",")[0]
+	var data struct {
+		Results []struct{ Latitude, Longitude float64 }
+	}
+	if err := getJSON(weatherClient, "https://geocoding-api.open-meteo.com/v1/search?count=1&name="+url.QueryEscape(name), &data); err != nil {
+		return 0, 0, err
+	}
+	if len(data.Results) == 0 {
+		return 0, 0, fmt.Errorf("city %q not found", cfg.Weather.City)
+	}
+	return data.Results[0].Latitude, data.Results[0].Longitude, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webhookConfig enables the HTTP listener scripts and other machines can
+// post text to
+type webhookConfig struct {
+	// Listen is the address to listen on, e.g. "localhost:7117"
+	Listen string `json:"listen,omitempty"`
+	// Token has to be sent as "Authorization: Bearer <token>" if set
+	Token string `json:"token,omitempty" secret:"true"`
+}
+
+// pushed is text posted to the webhook
+type pushed struct {
+	text    string
+	expires time.Time
+}
+
+var webhook struct {
+	sync.Mutex
+	slots map[string]pushed
+}
+
+// updateWebhook shows the texts posted to the webhook that haven't expired
+// yet, ordered by their slot
+func updateWebhook() string {
+	webhook.Lock()
+	defer webhook.Unlock()
+	var names []string
+	for name, slot := range webhook.slots {
+		if !slot.expires.IsZero() && time.Now().After(slot.expires) {
+			delete(webhook.slots, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var fields []string
+	for _, name := range names {
+		fields = append(fields, webhook.slots[name].text)
+	}
+	return strings.Join(fields, fieldSeparator)
+}
+
+// serveWebhook accepts the posted texts. The path names the slot a text is
+// shown in, posting to it again replaces the text and DELETE removes it.
+func serveWebhook() {
+	if cfg.Webhook.Listen == "" {
+		return
+	}
+	webhook.slots = map[string]pushed{}
+	var err = http.ListenAndServe(cfg.Webhook.Listen, http.HandlerFunc(handleWebhook))
+	fmt.Fprintln(os.Stderr, "gods: webhook:", err)
+}
+
+// handleWebhook takes plain text or JSON like {"text": "CI failed",
+// "expire": "1h"}, the expiry can also be given as ?expire=1h
+func handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if cfg.Webhook.Token != "" && r.Header.Get("Authorization") != "Bearer "+cfg.Webhook.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var name = strings.Trim(r.URL.Path, "/")
+	switch r.Method {
+	case "DELETE":
+		webhook.Lock()
+		delete(webhook.slots, name)
+		webhook.Unlock()
+		refresh("webhook")
+		return
+	case "POST", "PUT":
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 4096))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var message = struct {
+		Text   string
+		Expire string
+	}{Text: string(body), Expire: r.URL.Query().Get("expire")}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err = json.Unmarshal(body, &message); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var slot = pushed{text: strings.Join(strings.Fields(message.Text), " ")}
+	if message.Expire != "" {
+		var expire, err = time.ParseDuration(message.Expire)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slot.expires = time.Now().Add(expire)
+	}
+	webhook.Lock()
+	if slot.text == "" {
+		delete(webhook.slots, name)
+	} else {
+		webhook.slots[name] = slot
+	}
+	webhook.Unlock()
+	refresh("webhook")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// initConfig is `gods init`: it looks at what this machine has and writes a
+// starter config choosing the output and audio backend that work and hiding
+// the modules that can only show ERR here. It returns the exit code.
+func initConfig() int {
+	var in = bufio.NewReader(os.Stdin)
+	var path = configPath()
+	if _, err := os.Stat(path); err == nil && !confirm(in, path+" exists, overwrite it?") {
+		return 1
+	}
+
+	var starter = map[string]interface{}{}
+	var hide []string
+	var leaveOut = func(name string) {
+		if enabled(name) {
+			hide = append(hide, name)
+		}
+	}
+	var report = func(format string, args ...interface{}) {
+		fmt.Printf("  "+format+"\n", args...)
+	}
+	fmt.Println("Looking at this machine:")
+
+	switch {
+	case os.Getenv("DISPLAY") != "":
+		starter["output"] = "xsetroot"
+		report("X display %s, the bar goes into the root window name for dwm", os.Getenv("DISPLAY"))
+	case os.Getenv("TMUX") != "":
+		starter["output"] = "tmux"
+		report("no X display but tmux, the bar goes into its status line")
+	default:
+		starter["output"] = "stdout"
+		report("no X display, the bar is printed to stdout")
+	}
+
+	var backend = ""
+	for _, name := range cfg.Volume.Backends {
+		if _, _, err := volumeBackends[name].read(); err == nil {
+			backend = name
+			break
+		}
+	}
+	if backend != "" {
+		starter["volume"] = map[string]interface{}{"backends": []string{backend}}
+		report("sound through %s", backend)
+	} else {
+		leaveOut("volume")
+		report("no sound server or mixer found, hiding the volume")
+	}
+
+	if _, err := sys.readBattery(); err == errNoBattery {
+		starter["power"] = map[string]interface{}{"desktop": "plugged"}
+		report("no battery, showing a plug instead")
+	} else if err != nil {
+		leaveOut("power")
+		report("battery unreadable (%v), hiding it", err)
+	} else {
+		report("battery found")
+	}
+
+	if temp, err := sys.cpuTemperature(); err != nil {
+		leaveOut("cputemp")
+		report("no CPU temperature sensor, hiding the temperature")
+	} else {
+		report("CPU at %.0f°C", temp)
+	}
+
+	if runtime.GOOS == "linux" {
+		if dirs, _ := filepath.Glob(sysPath("/sys/class/net/*/wireless")); len(dirs) == 0 {
+			leaveOut("wifi")
+			report("no wireless interface, hiding wifi")
+		} else {
+			report("wireless interface %s", filepath.Base(filepath.Dir(dirs[0])))
+		}
+	}
+
+	if _, err := kbdLight(); err != nil {
+		leaveOut("kbdlight")
+	} else {
+		report("keyboard backlight found")
+	}
+
+	if out, err := timedCommand("fc-list", ":", "family").Output(); err != nil {
+		report("fc-list not found, make sure the bar's font is a Nerd Font")
+	} else if !strings.Contains(strings.ToLower(string(out)), "nerd font") {
+		report("no Nerd Font installed, the icons need one: https://www.nerdfonts.com")
+	} else {
+		report("Nerd Font installed")
+	}
+
+	if len(hide) > 0 {
+		starter["hide"] = hide
+	}
+	var data, _ = json.MarshalIndent(starter, "", "\t")
+	fmt.Printf("\n%s\n\n", data)
+	if !confirm(in, "Write this to "+path+"?") {
+		return 1
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		return 1
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "gods:", err)
+		return 1
+	}
+	fmt.Println("Done, the README lists everything else that can be set there.")
+	return 0
+}
+
+// confirm asks a yes or no question on stdout, no is the default
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	var answer, _ = in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// xConn is a minimal X11 client speaking just enough of the core protocol
+// and the XKB extension to follow the keyboard state
+type xConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	// xkb is the major opcode of the XKB extension, xkbEvent its event code
+	xkb, xkbEvent byte
+}
+
+// XKB requests, events and masks from XKBproto.h
+const (
+	xkbUseExtension = 0
+	xkbSelectEvents = 1
+	xkbGetState     = 4
+
+	xkbStateNotify = 2
+
+	xkbModifierLockMask = 1 << 3
+	xkbGroupStateMask   = 1 << 4
+
+	xkbUseCoreKbd = 0x100
+)
+
+// xkbState is the part of the keyboard state gods shows
+type xkbState struct {
+	group      int
+	lockedMods byte
+}
+
+// dialX connects to the display in $DISPLAY, authenticating with the cookie
+// from the Xauthority file, and initializes the XKB extension
+func dialX() (*xConn, error) {
+	var display = os.Getenv("DISPLAY")
+	var i = strings.LastIndex(display, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("x11: bad DISPLAY %q", display)
+	}
+	var number = strings.SplitN(display[i+1:], ".", 2)[0]
+	// only local displays are supported, which covers the status bar
+	conn, err := net.DialTimeout("unix", "/tmp/.X11-unix/X"+number, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var c = &xConn{conn: conn, r: bufio.NewReader(conn)}
+	if err = c.setup(number); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// setup sends the connection setup and skips the server's description
+func (c *xConn) setup(number string) error {
+	var name, cookie = xauthCookie(number)
+	var req = []byte{'l', 0, 11, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(req[6:], uint16(len(name)))
+	binary.LittleEndian.PutUint16(req[8:], uint16(len(cookie)))
+	req = append(append(req, xPad([]byte(name))...), xPad(cookie)...)
+
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+	if _, err := c.conn.Write(req); err != nil {
+		return err
+	}
+	var head = make([]byte, 8)
+	if _, err := io.ReadFull(c.r, head); err != nil {
+		return err
+	}
+	var rest = make([]byte, 4*int(binary.LittleEndian.Uint16(head[6:])))
+	if _, err := io.ReadFull(c.r, rest); err != nil {
+		return err
+	}
+	if head[0] != 1 {
+		return fmt.Errorf("x11: %s", strings.TrimSpace(string(rest[:head[1]])))
+	}
+
+	// QueryExtension
+	var extension = xPad([]byte("XKEYBOARD"))
+	req = []byte{98, 0, 0, 0, 9, 0, 0, 0}
+	binary.LittleEndian.PutUint16(req[2:], uint16(2+len(extension)/4))
+	reply, err := c.request(append(req, extension...))
+	if err != nil {
+		return err
+	} else if reply[8] == 0 {
+		return fmt.Errorf("x11: no XKEYBOARD extension")
+	}
+	c.xkb, c.xkbEvent = reply[9], reply[10]
+
+	// XkbUseExtension 1.0
+	_, err = c.request([]byte{c.xkb, xkbUseExtension, 2, 0, 1, 0, 0, 0})
+	return err
+}
+
+// request sends a request and returns the reply, events received in the
+// meantime are dropped
+func (c *xConn) request(req []byte) ([]byte, error) {
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+	for {
+		var msg, err = c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch msg[0] {
+		case 0:
+			return nil, fmt.Errorf("x11: error %d for request %d", msg[1], req[0])
+		case 1:
+			return msg, nil
+		}
+	}
+}
+
+// read returns the next reply, error or event
+func (c *xConn) read() ([]byte, error) {
+	var msg = make([]byte, 32)
+	if _, err := io.ReadFull(c.r, msg); err != nil {
+		return nil, err
+	}
+	if msg[0] == 1 {
+		var extra = make([]byte, 4*int(binary.LittleEndian.Uint32(msg[4:])))
+		if _, err := io.ReadFull(c.r, extra); err != nil {
+			return nil, err
+		}
+		msg = append(msg, extra...)
+	}
+	return msg, nil
+}
+
+// xkbState returns the current keyboard state
+func (c *xConn) xkbState() (xkbState, error) {
+	var reply, err = c.request([]byte{c.xkb, xkbGetState, 2, 0, xkbUseCoreKbd & 0xff, xkbUseCoreKbd >> 8, 0, 0})
+	if err != nil {
+		return xkbState{}, err
+	}
+	return xkbState{group: int(reply[12]), lockedMods: reply[11]}, nil
+}
+
+// watchXkbState calls changed with the keyboard state whenever the layout
+// group or the locked modifiers change, until the connection breaks
+func (c *xConn) watchXkbState(changed func(xkbState)) error {
+	var req = make([]byte, 20)
+	req[0], req[1], req[2] = c.xkb, xkbSelectEvents, 5
+	binary.LittleEndian.PutUint16(req[4:], xkbUseCoreKbd)
+	binary.LittleEndian.PutUint16(req[6:], 1<<xkbStateNotify) // affectWhich
+	var details = uint16(xkbGroupStateMask | xkbModifierLockMask)
+	binary.LittleEndian.PutUint16(req[16:], details) // affectState
+	binary.LittleEndian.PutUint16(req[18:], details) // stateDetails
+	if _, err := c.conn.Write(req); err != nil {
+		return err
+	}
+
+	var state, err = c.xkbState()
+	if err != nil {
+		return err
+	}
+	changed(state)
+	for {
+		var msg, err = c.read()
+		if err != nil {
+			return err
+		}
+		if msg[0]&0x7f == c.xkbEvent && msg[1] == xkbStateNotify {
+			changed(xkbState{group: int(msg[13]), lockedMods: msg[12]})
+		}
+	}
+}
+
+// xauthCookie looks up the MIT-MAGIC-COOKIE-1 for the local display number
+// in the Xauthority file
+func xauthCookie(number string) (string, []byte) {
+	var path = os.Getenv("XAUTHORITY")
+	if path == "" {
+		path = filepath.Join(os.Getenv("HOME"), ".Xauthority")
+	}
+	var data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	var hostname, _ = os.Hostname()
+	var field = func() []byte {
+		if len(data) < 2 {
+			data = nil
+			return nil
+		}
+		var n = int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+n {
+			data = nil
+			return nil
+		}
+		var f = data[2 : 2+n]
+		data = data[2+n:]
+		return f
+	}
+	for len(data) >= 2 {
+		var family = binary.BigEndian.Uint16(data)
+		data = data[2:]
+		var address, display, name, cookie = field(), field(), field(), field()
+		// 256 is FamilyLocal, 65535 FamilyWild
+		var local = family == 256 && string(address) == hostname || family == 65535
+		if local && (string(display) == number || len(display) == 0) && string(name) == "MIT-MAGIC-COOKIE-1" {
+			return string(name), cookie
+		}
+	}
+	return "", nil
+}
+
+// xPad pads b to a multiple of four bytes
+func xPad(b []byte) []byte {
+	return append(b, make([]byte, (4-len(b)%4)%4)...)
+}